@@ -32,3 +32,40 @@ func newData(assetData *cell.Slice) *Data {
 		AwaitedSupply:       assetData.MustLoadBigUInt(64),
 	}
 }
+
+// AccrueInterest returns the data with the rates accrued at the per-second interest from LastAccrual up to ts.
+func (d *Data) AccrueInterest(supplyInterest, borrowInterest *big.Int, ts int64) *Data {
+	timeElapsed := big.NewInt(ts - d.LastAccrual.Int64())
+	if timeElapsed.Sign() <= 0 {
+		return d
+	}
+
+	accrued := *d
+	accrued.SRate = new(big.Int).Add(d.SRate, mulDiv(d.SRate, new(big.Int).Mul(supplyInterest, timeElapsed), big.NewInt(1e12)))
+	accrued.BRate = new(big.Int).Add(d.BRate, mulDiv(d.BRate, new(big.Int).Mul(borrowInterest, timeElapsed), big.NewInt(1e12)))
+	accrued.LastAccrual = big.NewInt(ts)
+	return &accrued
+}
+
+// TrackingIndexesAt estimates the reward tracking indexes accrued from LastAccrual up to ts.
+// The indexes grow by the tracking speed per principal unit while the total principal is at least MinPrincipalForRewards,
+// rewards are disabled if MinPrincipalForRewards is zero. These rules follow the config field names,
+// they are not verified against the contract.
+func (d *Data) TrackingIndexesAt(config *Config, ts int64) (supplyIndex, borrowIndex *big.Int) {
+	supplyIndex, borrowIndex = new(big.Int).Set(d.TrackingSupplyIndex), new(big.Int).Set(d.TrackingBorrowIndex)
+	timeElapsed := big.NewInt(ts - d.LastAccrual.Int64())
+	if config.MinPrincipalForRewards.Sign() == 0 || timeElapsed.Sign() <= 0 {
+		return supplyIndex, borrowIndex
+	}
+
+	scale := config.Scale()
+	accrue := func(index, speed, total *big.Int) {
+		if total.Sign() == 0 || new(big.Int).Div(total, scale).Cmp(config.MinPrincipalForRewards) == -1 {
+			return
+		}
+		index.Add(index, mulDiv(new(big.Int).Mul(speed, timeElapsed), scale, total))
+	}
+	accrue(supplyIndex, config.BaseTrackingSupplySpeed, d.TotalSupply)
+	accrue(borrowIndex, config.BaseTrackingBorrowSpeed, d.TotalBorrow)
+	return supplyIndex, borrowIndex
+}
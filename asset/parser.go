@@ -124,12 +124,26 @@ func (p *Parser) CalculateCurrentRates(asset string) (*Data, *big.Int, *big.Int)
 	return p.calculateCurrentRates(asset, time.Now().Unix())
 }
 
+// CalculateRatesAt works like CalculateCurrentRates for the moment ts, e.g. when a message is expected to land.
+func (p *Parser) CalculateRatesAt(asset string, ts int64) (*Data, *big.Int, *big.Int) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	return p.calculateCurrentRates(asset, ts)
+}
+
 func (p *Parser) calculateCurrentRates(asset string, ts int64) (_ *Data, supplyInterest, borrowInterest *big.Int) {
 	assetData := p.data[asset]
-	timeElapsed := ts - assetData.LastAccrual.Int64()
-	if timeElapsed <= 0 {
+	if ts-assetData.LastAccrual.Int64() <= 0 {
 		return assetData, big.NewInt(0), big.NewInt(0)
 	}
+	supplyInterest, borrowInterest = CalculateInterest(assetData, p.config[asset])
+	return assetData.AccrueInterest(supplyInterest, borrowInterest, ts), supplyInterest, borrowInterest
+}
+
+// CalculateInterest returns the per-second supply and borrow interest scaled by FactorScale
+// at the utilization of the asset data.
+func CalculateInterest(assetData *Data, assetConfig *Config) (supplyInterest, borrowInterest *big.Int) {
 	totalSupply := mulDiv(assetData.SRate, assetData.TotalSupply, big.NewInt(1e12))
 	totalBorrow := mulDiv(assetData.BRate, assetData.TotalBorrow, big.NewInt(1e12))
 
@@ -138,8 +152,6 @@ func (p *Parser) calculateCurrentRates(asset string, ts int64) (_ *Data, supplyI
 		utilization = mulDiv(totalBorrow, big.NewInt(1e12), totalSupply)
 	}
 
-	assetConfig := p.config[asset]
-
 	if utilization.Cmp(assetConfig.TargetUtilization) != 1 {
 		borrowInterest = new(big.Int).Add(assetConfig.BaseBorrowRate,
 			mulDiv(assetConfig.BorrowRateSlopeLow, utilization, big.NewInt(1e12)))
@@ -151,16 +163,7 @@ func (p *Parser) calculateCurrentRates(asset string, ts int64) (_ *Data, supplyI
 	}
 	supplyInterest = mulDiv(mulDiv(borrowInterest, utilization, big.NewInt(1e12)),
 		new(big.Int).Sub(big.NewInt(10_000), assetConfig.ReserveFactor), big.NewInt(10_000))
-
-	timeElapsedBigInt := big.NewInt(timeElapsed)
-
-	return &Data{
-		SRate:       new(big.Int).Add(assetData.SRate, mulDiv(assetData.SRate, new(big.Int).Mul(supplyInterest, timeElapsedBigInt), big.NewInt(1e12))),
-		BRate:       new(big.Int).Add(assetData.BRate, mulDiv(assetData.BRate, new(big.Int).Mul(borrowInterest, timeElapsedBigInt), big.NewInt(1e12))),
-		TotalSupply: assetData.TotalSupply,
-		TotalBorrow: assetData.TotalBorrow,
-		LastAccrual: big.NewInt(ts),
-	}, supplyInterest, borrowInterest
+	return supplyInterest, borrowInterest
 }
 
 func mulDiv(x, y, z *big.Int) *big.Int {
@@ -0,0 +1,159 @@
+package price
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	ErrPricesNotReady = errors.New("prices are not fetched yet")
+	ErrPricesExpired  = errors.New("prices are expired")
+)
+
+// CachedService keeps the latest prices fetched by a Service in the background,
+// so consumers get them instantly instead of querying the oracles on every call.
+type CachedService struct {
+	service  *Service
+	interval time.Duration
+	endpoint []string
+
+	mtx    sync.RWMutex
+	prices *Prices
+	err    error
+	// notified are the prices subscribers were last notified of, nil after a failed refresh
+	notified    *Prices
+	subscribers map[chan *Update]struct{}
+}
+
+// DefaultRefreshInterval is the refresh interval of a CachedService created with a non-positive one.
+const DefaultRefreshInterval = 10 * time.Second
+
+func NewCachedService(service *Service, interval time.Duration, endpoint ...string) *CachedService {
+	if interval <= 0 {
+		interval = DefaultRefreshInterval
+	}
+	return &CachedService{
+		service:     service,
+		interval:    interval,
+		endpoint:    endpoint,
+		subscribers: make(map[chan *Update]struct{}),
+	}
+}
+
+// Run refreshes prices immediately and then every interval until ctx is done.
+// Refresh errors do not stop it, they are reported by Prices once the cached data is no longer valid.
+func (s *CachedService) Run(ctx context.Context) error {
+	t := time.NewTicker(s.interval)
+	defer t.Stop()
+
+	for {
+		s.Refresh(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+// Refresh fetches prices once and notifies subscribers of the result. Fresh prices are only sent
+// when they are worth sending to subscribers who have seen the previously sent ones, see Service.Subscribe.
+func (s *CachedService) Refresh(ctx context.Context) {
+	prices, err := s.service.GetPrices(ctx, s.endpoint...)
+	if ctx.Err() != nil {
+		return
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if err != nil {
+		s.err = err
+		s.notified = nil
+		s.broadcast(&Update{Err: err})
+		return
+	}
+	s.err = nil
+	s.prices = prices
+
+	if !s.service.changed(s.notified, prices, s.interval) {
+		return
+	}
+	s.notified = prices
+	s.broadcast(&Update{Prices: prices})
+}
+
+func (s *CachedService) broadcast(update *Update) {
+	for ch := range s.subscribers {
+		notify(ch, update)
+	}
+}
+
+// Prices returns the latest prices as long as none of their oracle data is older than the policy MaxAge.
+func (s *CachedService) Prices() (*Prices, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	if s.prices == nil {
+		if s.err != nil {
+			return nil, fmt.Errorf("%w, err: %w", ErrPricesNotReady, s.err)
+		}
+		return nil, ErrPricesNotReady
+	}
+	if s.expired(s.prices) {
+		if s.err != nil {
+			return nil, fmt.Errorf("%w, err: %w", ErrPricesExpired, s.err)
+		}
+		return nil, ErrPricesExpired
+	}
+	return s.prices, nil
+}
+
+// Subscribe returns a channel which receives updates of every refresh until ctx is done,
+// the latest valid prices are sent right away. A slow subscriber only gets the most recent update,
+// older undelivered ones are dropped. The channel is closed once ctx is done.
+func (s *CachedService) Subscribe(ctx context.Context) <-chan *Update {
+	ch := make(chan *Update, 1)
+
+	s.mtx.Lock()
+	s.subscribers[ch] = struct{}{}
+	if s.prices != nil && !s.expired(s.prices) {
+		ch <- &Update{Prices: s.prices}
+	}
+	s.mtx.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+
+		delete(s.subscribers, ch)
+		close(ch)
+	}()
+
+	return ch
+}
+
+func (s *CachedService) expired(prices *Prices) bool {
+	return time.Since(time.Unix(prices.MinTimestamp(), 0)) > s.service.policy.MaxAge
+}
+
+// notify replaces a pending value with the new one instead of blocking on a slow receiver.
+func notify[T any](ch chan T, value T) {
+	for {
+		select {
+		case ch <- value:
+			return
+		default:
+		}
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
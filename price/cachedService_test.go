@@ -0,0 +1,151 @@
+package price
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+type stubProvider func(ctx context.Context, baseURL, address string) (*RawData, error)
+
+func (p stubProvider) GetRawData(ctx context.Context, baseURL, address string) (*RawData, error) {
+	return p(ctx, baseURL, address)
+}
+
+// newTestRawData builds unsigned oracle data pricing every asset of the config at price.
+func newTestRawData(assets map[string]*config.AssetConfig, timestamp int64, price int64) *RawData {
+	dict := cell.NewDict(256)
+	for _, asset := range assets {
+		_ = dict.SetIntKey(asset.ID, cell.BeginCell().MustStoreBigCoins(big.NewInt(price)).EndCell())
+	}
+	return &RawData{
+		PricesDict: dict,
+		Signature:  make([]byte, 64),
+		PubKey:     make([]byte, 32),
+		Timestamp:  timestamp,
+	}
+}
+
+func TestCachedService(t *testing.T) {
+	cfg := config.GetMainMainnetConfig()
+	var price atomic.Int64
+	price.Store(1_000_000_000)
+	var fail atomic.Bool
+	provider := stubProvider(func(context.Context, string, string) (*RawData, error) {
+		if fail.Load() {
+			return nil, errors.New("oracle is down")
+		}
+		return newTestRawData(cfg.Assets, time.Now().Unix(), price.Load()), nil
+	})
+	cached := NewCachedService(NewService(cfg, provider), time.Hour)
+
+	if _, err := cached.Prices(); !errors.Is(err, ErrPricesNotReady) {
+		t.Fatalf("Prices err want %s, got %v", ErrPricesNotReady, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := cached.Subscribe(ctx)
+
+	cached.Refresh(context.Background())
+	prices, err := cached.Prices()
+	if err != nil {
+		t.Fatalf("failed to get prices, err: %s", err)
+	}
+	if got := prices.Get(config.TON.ID()); got.Cmp(big.NewInt(1_000_000_000)) != 0 {
+		t.Errorf("TON price want %d, got %s", 1_000_000_000, got)
+	}
+	select {
+	case received := <-ch:
+		if received.Prices != prices {
+			t.Errorf("subscriber received unexpected update %+v", received)
+		}
+	default:
+		t.Errorf("subscriber was not notified")
+	}
+
+	price.Store(2_000_000_000)
+	cached.Refresh(context.Background())
+	price.Store(3_000_000_000)
+	cached.Refresh(context.Background())
+	select {
+	case received := <-ch:
+		if got := received.Prices.Get(config.TON.ID()); got.Cmp(big.NewInt(3_000_000_000)) != 0 {
+			t.Errorf("slow subscriber TON price want %d, got %s", 3_000_000_000, got)
+		}
+	default:
+		t.Errorf("subscriber was not notified")
+	}
+
+	fail.Store(true)
+	cached.Refresh(context.Background())
+	if _, err := cached.Prices(); err != nil {
+		t.Errorf("cached prices should outlive a failed refresh, err: %s", err)
+	}
+	select {
+	case received := <-ch:
+		if received.Err == nil {
+			t.Errorf("subscriber want error update, got %+v", received)
+		}
+	default:
+		t.Errorf("subscriber was not notified of the error")
+	}
+
+	cached.prices.minTimestamp = time.Now().Add(-ttlOracleData - time.Second).Unix()
+	if _, err := cached.Prices(); !errors.Is(err, ErrPricesExpired) {
+		t.Errorf("Prices err want %s, got %v", ErrPricesExpired, err)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Errorf("channel should be closed after cancel")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("channel is not closed after cancel")
+	}
+}
+
+func TestCachedService_Run(t *testing.T) {
+	cfg := config.GetMainMainnetConfig()
+	var calls atomic.Int32
+	provider := stubProvider(func(context.Context, string, string) (*RawData, error) {
+		calls.Add(1)
+		return newTestRawData(cfg.Assets, time.Now().Unix(), int64(calls.Load())), nil
+	})
+	cached := NewCachedService(NewService(cfg, provider), 10*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := cached.Subscribe(ctx)
+
+	done := make(chan error)
+	go func() { done <- cached.Run(ctx) }()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-ch:
+		case <-time.After(time.Second):
+			t.Fatalf("no prices received")
+		}
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run want nil, got %s", err)
+	}
+}
+
+func TestNewCachedService_interval(t *testing.T) {
+	service := NewService(config.GetMainMainnetConfig(), nil)
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if got := NewCachedService(service, interval).interval; got != DefaultRefreshInterval {
+			t.Errorf("interval %s want %s, got %s", interval, DefaultRefreshInterval, got)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package price
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var _ Provider = (*CompositeProvider)(nil)
+
+// CompositeProvider tries its providers in priority order and returns the first data received.
+type CompositeProvider struct {
+	providers []Provider
+	maxAge    time.Duration
+}
+
+// NewCompositeProvider creates a provider trying the providers in the given order. If maxAge is positive,
+// data older than it is treated as a failure, so a stale source does not shadow the fresher ones.
+func NewCompositeProvider(maxAge time.Duration, providers ...Provider) *CompositeProvider {
+	return &CompositeProvider{providers: providers, maxAge: maxAge}
+}
+
+func (p *CompositeProvider) GetRawData(ctx context.Context, baseURL, address string) (*RawData, error) {
+	errs := make([]error, 0, len(p.providers))
+	for i, provider := range p.providers {
+		rawData, err := provider.GetRawData(ctx, baseURL, address)
+		if err == nil && p.maxAge > 0 && time.Since(time.Unix(rawData.Timestamp, 0)) > p.maxAge {
+			err = fmt.Errorf("%w: timestamp %d", ErrOracleOutdated, rawData.Timestamp)
+		}
+		if err == nil {
+			return rawData, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		errs = append(errs, fmt.Errorf("provider %d: %w", i, err))
+	}
+	return nil, fmt.Errorf("all providers failed: %w", errors.Join(errs...))
+}
@@ -0,0 +1,43 @@
+package price
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+func TestCompositeProvider_GetRawData(t *testing.T) {
+	cfg := config.GetMainMainnetConfig()
+	fresh := newTestRawData(cfg.Assets, time.Now().Unix(), 200)
+	stale := newTestRawData(cfg.Assets, time.Now().Add(-time.Hour).Unix(), 100)
+
+	failing := stubProvider(func(context.Context, string, string) (*RawData, error) {
+		return nil, errors.New("source is down")
+	})
+	staleSource := stubProvider(func(context.Context, string, string) (*RawData, error) { return stale, nil })
+	freshSource := stubProvider(func(context.Context, string, string) (*RawData, error) { return fresh, nil })
+
+	rawData, err := NewCompositeProvider(time.Minute, failing, staleSource, freshSource).GetRawData(context.Background(), "", testOracleAddress)
+	if err != nil {
+		t.Fatalf("failed to GetRawData, err: %s", err)
+	}
+	if rawData != fresh {
+		t.Errorf("want data of the fresh source")
+	}
+
+	rawData, err = NewCompositeProvider(0, staleSource, freshSource).GetRawData(context.Background(), "", testOracleAddress)
+	if err != nil {
+		t.Fatalf("failed to GetRawData, err: %s", err)
+	}
+	if rawData != stale {
+		t.Errorf("without maxAge want data of the first source")
+	}
+
+	_, err = NewCompositeProvider(time.Minute, failing, staleSource).GetRawData(context.Background(), "", testOracleAddress)
+	if !errors.Is(err, ErrOracleOutdated) {
+		t.Errorf("error want %s, got %v", ErrOracleOutdated, err)
+	}
+}
@@ -0,0 +1,173 @@
+package price
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+)
+
+type AlertKind int
+
+const (
+	// AlertDeviation means an oracle price is too far from the median of all valid oracles.
+	AlertDeviation AlertKind = iota
+	// AlertJump means the median price has moved too much since the previous update.
+	AlertJump
+)
+
+func (k AlertKind) String() string {
+	switch k {
+	case AlertDeviation:
+		return "deviation"
+	case AlertJump:
+		return "jump"
+	default:
+		return "unknown"
+	}
+}
+
+type Alert struct {
+	Kind  AlertKind
+	Asset string
+	// OracleID is the deviating oracle, it is only set for AlertDeviation.
+	OracleID uint64
+	// Price is the oracle price for AlertDeviation and the new median for AlertJump.
+	Price *big.Int
+	// Reference is the median of all valid oracles for AlertDeviation and the previous median for AlertJump.
+	Reference *big.Int
+	// Deviation is |Price - Reference| / Reference in basis points.
+	Deviation *big.Int
+	Timestamp int64
+}
+
+// DetectorConfig holds the alert thresholds in basis points, zero disables the corresponding check.
+type DetectorConfig struct {
+	MaxDeviation      uint64
+	AssetMaxDeviation map[string]uint64
+	MaxJump           uint64
+	AssetMaxJump      map[string]uint64
+}
+
+// Detector looks for possibly manipulated prices by comparing every oracle to the median
+// and every median to the previous one.
+type Detector struct {
+	config *DetectorConfig
+	alert  func(*Alert)
+
+	mtx  sync.Mutex
+	last map[string]*big.Int
+}
+
+// NewDetector creates a detector which calls alert for every detected anomaly, alert may be nil.
+func NewDetector(config *DetectorConfig, alert func(*Alert)) *Detector {
+	return &Detector{config: config, alert: alert, last: make(map[string]*big.Int)}
+}
+
+// Inspect checks the oracles of the report and the resulting prices, which may be nil
+// if they were not obtained, and returns the detected alerts after passing them to the callback.
+func (d *Detector) Inspect(prices *Prices, report *Report) []*Alert {
+	var alerts []*Alert
+	if report != nil {
+		alerts = append(alerts, d.inspectDeviations(report)...)
+	}
+	if prices != nil {
+		alerts = append(alerts, d.inspectJumps(prices)...)
+	}
+
+	if d.alert != nil {
+		for _, alert := range alerts {
+			d.alert(alert)
+		}
+	}
+	return alerts
+}
+
+func (d *Detector) inspectDeviations(report *Report) []*Alert {
+	ids := make([]uint64, 0, len(report.Oracles))
+	for id := range report.Oracles {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var alerts []*Alert
+	for _, id := range ids {
+		oracle := report.Oracles[id]
+		if !oracle.valid() {
+			continue
+		}
+		prices := oracle.Data.Prices()
+		for _, asset := range sortedKeys(report.Spread) {
+			median := report.Spread[asset].Median
+			maxDeviation := threshold(d.config.MaxDeviation, d.config.AssetMaxDeviation, asset)
+			if maxDeviation == 0 || median == nil || median.Sign() != 1 {
+				continue
+			}
+			deviation := deviationBps(prices[asset], median)
+			if deviation.Cmp(new(big.Int).SetUint64(maxDeviation)) != 1 {
+				continue
+			}
+			alerts = append(alerts, &Alert{
+				Kind:      AlertDeviation,
+				Asset:     asset,
+				OracleID:  id,
+				Price:     prices[asset],
+				Reference: median,
+				Deviation: deviation,
+				Timestamp: oracle.Timestamp,
+			})
+		}
+	}
+	return alerts
+}
+
+func (d *Detector) inspectJumps(prices *Prices) []*Alert {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	var alerts []*Alert
+	for _, asset := range sortedKeys(prices.list) {
+		price := prices.list[asset]
+		previous, ok := d.last[asset]
+		d.last[asset] = price
+
+		maxJump := threshold(d.config.MaxJump, d.config.AssetMaxJump, asset)
+		if !ok || maxJump == 0 || previous.Sign() != 1 {
+			continue
+		}
+		deviation := deviationBps(price, previous)
+		if deviation.Cmp(new(big.Int).SetUint64(maxJump)) != 1 {
+			continue
+		}
+		alerts = append(alerts, &Alert{
+			Kind:      AlertJump,
+			Asset:     asset,
+			Price:     price,
+			Reference: previous,
+			Deviation: deviation,
+			Timestamp: prices.MinTimestamp(),
+		})
+	}
+	return alerts
+}
+
+// valid reports whether the oracle data has passed the policy checks, whether or not it was used.
+func (r *OracleReport) valid() bool {
+	return r.Data != nil && (r.Reason == nil || errors.Is(r.Reason, ErrOracleNotSelected) || errors.Is(r.Reason, ErrOracleDeviation))
+}
+
+func threshold(value uint64, perAsset map[string]uint64, asset string) uint64 {
+	if v, ok := perAsset[asset]; ok {
+		return v
+	}
+	return value
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
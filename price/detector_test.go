@@ -0,0 +1,70 @@
+package price
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+func TestDetector(t *testing.T) {
+	cfg := config.GetMainMainnetConfig()
+	tonAsset := config.TON.ID()
+	tonOnly := map[string]*config.AssetConfig{tonAsset: cfg.Assets[tonAsset]}
+	cfg.Assets = tonOnly
+
+	data := map[string]*RawData{}
+	setPrices := func(prices ...int64) {
+		now := time.Now().Unix()
+		for i, price := range prices {
+			data[cfg.Oracles[i].Address] = newTestRawData(tonOnly, now-int64(i), price)
+		}
+	}
+
+	var received []*Alert
+	detector := NewDetector(&DetectorConfig{MaxDeviation: 1000, MaxJump: 2000}, func(alert *Alert) {
+		received = append(received, alert)
+	})
+	service := NewService(cfg, oracleStub(data)).SetDetector(detector)
+
+	setPrices(1000, 1010, 990, 1500)
+	_, report, err := service.GetPricesDetailed(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get prices, err: %s", err)
+	}
+	if len(report.Alerts) != 1 || len(received) != 1 {
+		t.Fatalf("alerts want %d, got %d reported and %d received", 1, len(report.Alerts), len(received))
+	}
+	alert := report.Alerts[0]
+	if alert.Kind != AlertDeviation || alert.OracleID != 3 || alert.Asset != tonAsset {
+		t.Errorf("alert want deviation of oracle 3, got %s of oracle %d", alert.Kind, alert.OracleID)
+	}
+	if alert.Price.Cmp(big.NewInt(1500)) != 0 || alert.Reference.Cmp(big.NewInt(1005)) != 0 {
+		t.Errorf("alert want price %d reference %d, got %s %s", 1500, 1005, alert.Price, alert.Reference)
+	}
+
+	setPrices(1100, 1110, 1090, 1100)
+	if _, report, err = service.GetPricesDetailed(context.Background()); err != nil {
+		t.Fatalf("failed to get prices, err: %s", err)
+	}
+	if len(report.Alerts) != 0 {
+		t.Errorf("alerts want %d, got %d", 0, len(report.Alerts))
+	}
+
+	setPrices(2000, 2010, 1990, 2000)
+	if _, report, err = service.GetPricesDetailed(context.Background()); err != nil {
+		t.Fatalf("failed to get prices, err: %s", err)
+	}
+	if len(report.Alerts) != 1 {
+		t.Fatalf("alerts want %d, got %d", 1, len(report.Alerts))
+	}
+	alert = report.Alerts[0]
+	if alert.Kind != AlertJump || alert.Price.Cmp(big.NewInt(2000)) != 0 || alert.Reference.Cmp(big.NewInt(1100)) != 0 {
+		t.Errorf("alert want jump from %d to %d, got %s from %s to %s", 1100, 2000, alert.Kind, alert.Reference, alert.Price)
+	}
+	if alert.Deviation.Cmp(big.NewInt(8181)) != 0 {
+		t.Errorf("Deviation want %d, got %s", 8181, alert.Deviation)
+	}
+}
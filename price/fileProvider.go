@@ -0,0 +1,86 @@
+package price
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var _ Provider = (*FileProvider)(nil)
+
+// FileProvider reads oracle data from local files, e.g. to replay recorded incidents.
+// The path is either a JSON file in the single endpoint format, a map of oracle address to feature data,
+// or a directory with a file per oracle address containing its feature data.
+// Files are read on every call, so they can be replaced while the provider is in use.
+type FileProvider struct {
+	path string
+}
+
+func NewFileProvider(path string) *FileProvider {
+	return &FileProvider{path: path}
+}
+
+func (p *FileProvider) GetRawData(_ context.Context, _, address string) (*RawData, error) {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s, err: %w", p.path, err)
+	}
+
+	var feature string
+	if info.IsDir() {
+		feature, err = p.readFeature(address)
+	} else {
+		feature, err = p.readList(address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rawData, err := Parse(feature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse data, err: %w", err)
+	}
+	return rawData, nil
+}
+
+func (p *FileProvider) readList(address string) (string, error) {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s, err: %w", p.path, err)
+	}
+
+	var list map[string]string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return "", fmt.Errorf("failed to decode %s, err: %w", p.path, err)
+	}
+	feature, ok := list[address]
+	if !ok {
+		return "", fmt.Errorf("no data for oracle %s in %s", address, p.path)
+	}
+	return feature, nil
+}
+
+func (p *FileProvider) readFeature(address string) (string, error) {
+	for _, name := range []string{address, address + ".txt", address + ".json"} {
+		data, err := os.ReadFile(filepath.Join(p.path, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read data of oracle %s, err: %w", address, err)
+		}
+
+		feature := strings.TrimSpace(string(data))
+		if strings.HasPrefix(feature, `"`) {
+			if err := json.Unmarshal([]byte(feature), &feature); err != nil {
+				return "", fmt.Errorf("failed to decode data of oracle %s, err: %w", address, err)
+			}
+		}
+		return feature, nil
+	}
+	return "", fmt.Errorf("no data for oracle %s in %s", address, p.path)
+}
@@ -0,0 +1,42 @@
+package price
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileProvider_GetRawData(t *testing.T) {
+	dir := t.TempDir()
+	list, err := json.Marshal(map[string]string{testOracleAddress: testFeature})
+	if err != nil {
+		t.Fatal(err)
+	}
+	listPath := filepath.Join(dir, "prices.json")
+	if err := os.WriteFile(listPath, list, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	oraclesDir := filepath.Join(dir, "oracles")
+	if err := os.Mkdir(oraclesDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(oraclesDir, testOracleAddress+".txt"), []byte(testFeature+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{listPath, oraclesDir} {
+		provider := NewFileProvider(path)
+		rawData, err := provider.GetRawData(context.Background(), "", testOracleAddress)
+		if err != nil {
+			t.Fatalf("failed to GetRawData from %s, err: %s", path, err)
+		}
+		if rawData.Timestamp != 1730559229 {
+			t.Errorf("Timestamp want %d, got %d", 1730559229, rawData.Timestamp)
+		}
+		if _, err := provider.GetRawData(context.Background(), "", "0x00"); err == nil {
+			t.Errorf("GetRawData of unknown oracle from %s want error, got nil", path)
+		}
+	}
+}
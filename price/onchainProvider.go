@@ -0,0 +1,108 @@
+package price
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+var _ Provider = (*OnchainProvider)(nil)
+
+// GetMethodRunner is the part of ton.APIClientWrapped used to run get-methods.
+type GetMethodRunner interface {
+	CurrentMasterchainInfo(ctx context.Context) (*ton.BlockIDExt, error)
+	RunGetMethod(ctx context.Context, blockInfo *ton.BlockIDExt, addr *address.Address, method string, params ...any) (*ton.ExecutionResult, error)
+}
+
+// OracleResultDecoder converts a get-method result into oracle data.
+type OracleResultDecoder func(result *ton.ExecutionResult) (*RawData, error)
+
+// OnchainProvider reads oracle data published by a TON contract. The get-method is called with
+// the oracle NFT address as a 256-bit integer and its result is converted by the decoder.
+type OnchainProvider struct {
+	api      GetMethodRunner
+	contract *address.Address
+	method   string
+	decode   OracleResultDecoder
+}
+
+// NewOnchainProvider creates a provider calling the method of the contract and converting its result
+// with the decoder. There is no standard oracle contract, so both depend on the contract being read,
+// DecodeOracleResult fits contracts returning the oracle data the way oracles sign it.
+func NewOnchainProvider(api GetMethodRunner, contract *address.Address, method string, decode OracleResultDecoder) (*OnchainProvider, error) {
+	if method == "" {
+		return nil, errors.New("get-method is not set")
+	}
+	if decode == nil {
+		return nil, errors.New("result decoder is not set")
+	}
+	return &OnchainProvider{api: api, contract: contract, method: method, decode: decode}, nil
+}
+
+func (p *OnchainProvider) GetRawData(ctx context.Context, _, oracleAddress string) (*RawData, error) {
+	oracle, ok := new(big.Int).SetString(strings.TrimPrefix(oracleAddress, "0x"), 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid oracle address %s", oracleAddress)
+	}
+
+	block, err := p.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info, err: %w", err)
+	}
+	result, err := p.api.RunGetMethod(ctx, block, p.contract, p.method, oracle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run %s, err: %w", p.method, err)
+	}
+
+	rawData, err := p.decode(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s result, err: %w", p.method, err)
+	}
+	return rawData, nil
+}
+
+// DecodeOracleResult decodes a result of the signed packed prices cell as oracles publish it,
+// the signature slice and the public key integer.
+func DecodeOracleResult(result *ton.ExecutionResult) (*RawData, error) {
+	packedPrices, err := result.Cell(0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load packed prices, err: %w", err)
+	}
+	signature, err := result.Slice(1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load signature, err: %w", err)
+	}
+	pubKey, err := result.Int(2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load public key, err: %w", err)
+	}
+	if pubKey.Sign() == -1 || pubKey.BitLen() > 256 {
+		return nil, fmt.Errorf("invalid public key %s", pubKey)
+	}
+
+	slice := packedPrices.BeginParse()
+	timestamp, err := slice.LoadUInt(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load timestamp, err: %w", err)
+	}
+	priceCell, err := slice.LoadRefCell()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load refCell from packedPrices, err: %w", err)
+	}
+	signatureBytes, err := signature.LoadSlice(signature.BitsLeft())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load signature bytes, err: %w", err)
+	}
+
+	return &RawData{
+		PricesDict: priceCell.AsDict(256),
+		Signature:  signatureBytes,
+		PubKey:     pubKey.FillBytes(make([]byte, 32)),
+		Timestamp:  int64(timestamp),
+	}, nil
+}
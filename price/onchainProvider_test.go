@@ -0,0 +1,92 @@
+package price
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+const testOracleGetMethod = "get_prices"
+
+type stubRunner struct {
+	method string
+	params []any
+	result *ton.ExecutionResult
+}
+
+func (r *stubRunner) CurrentMasterchainInfo(context.Context) (*ton.BlockIDExt, error) {
+	return &ton.BlockIDExt{}, nil
+}
+
+func (r *stubRunner) RunGetMethod(_ context.Context, _ *ton.BlockIDExt, _ *address.Address, method string, params ...any) (*ton.ExecutionResult, error) {
+	r.method, r.params = method, params
+	return r.result, nil
+}
+
+func TestOnchainProvider_GetRawData(t *testing.T) {
+	expected, err := Parse(testFeature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packedPrices := cell.BeginCell().
+		MustStoreUInt(uint64(expected.Timestamp), 32).
+		MustStoreMaybeRef(expected.PricesDict.AsCell()).
+		EndCell()
+	runner := &stubRunner{result: ton.NewExecutionResult([]any{
+		packedPrices,
+		cell.BeginCell().MustStoreSlice(expected.Signature, 512).EndCell().BeginParse(),
+		new(big.Int).SetBytes(expected.PubKey),
+	})}
+
+	provider, err := NewOnchainProvider(runner, address.MustParseAddr(config.MasterMainnet), testOracleGetMethod, DecodeOracleResult)
+	if err != nil {
+		t.Fatalf("failed to create provider, err: %s", err)
+	}
+	rawData, err := provider.GetRawData(context.Background(), "", testOracleAddress)
+	if err != nil {
+		t.Fatalf("failed to GetRawData, err: %s", err)
+	}
+	if runner.method != testOracleGetMethod {
+		t.Errorf("method want %s, got %s", testOracleGetMethod, runner.method)
+	}
+	if oracle, ok := runner.params[0].(*big.Int); !ok || oracle.Text(16) != testOracleAddress[2:] {
+		t.Errorf("oracle param want %s, got %v", testOracleAddress, runner.params[0])
+	}
+	if rawData.Timestamp != expected.Timestamp {
+		t.Errorf("Timestamp want %d, got %d", expected.Timestamp, rawData.Timestamp)
+	}
+	if !bytes.Equal(rawData.Signature, expected.Signature) || !bytes.Equal(rawData.PubKey, expected.PubKey) {
+		t.Errorf("signature or public key differ")
+	}
+	if !bytes.Equal(rawData.PricesDict.AsCell().Hash(), expected.PricesDict.AsCell().Hash()) {
+		t.Errorf("PricesDict differs")
+	}
+}
+
+func TestNewOnchainProvider_required(t *testing.T) {
+	contract := address.MustParseAddr(config.MasterMainnet)
+	if _, err := NewOnchainProvider(&stubRunner{}, contract, "", DecodeOracleResult); err == nil {
+		t.Errorf("provider without a get-method want error, got nil")
+	}
+	if _, err := NewOnchainProvider(&stubRunner{}, contract, testOracleGetMethod, nil); err == nil {
+		t.Errorf("provider without a decoder want error, got nil")
+	}
+}
+
+func TestDecodeOracleResult_invalidPubKey(t *testing.T) {
+	result := ton.NewExecutionResult([]any{
+		cell.BeginCell().MustStoreUInt(0, 32).MustStoreRef(cell.BeginCell().EndCell()).EndCell(),
+		cell.BeginCell().MustStoreSlice(make([]byte, 64), 512).EndCell().BeginParse(),
+		new(big.Int).Lsh(big.NewInt(1), 256),
+	})
+	if _, err := DecodeOracleResult(result); err == nil {
+		t.Errorf("DecodeOracleResult with a 257-bit public key want error, got nil")
+	}
+}
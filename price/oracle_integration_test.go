@@ -0,0 +1,96 @@
+//go:build integration
+// +build integration
+
+package price
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+const liveOracleAddress = "0xd3a8c0b9fd44fd25a49289c631e3ac45689281f2f8cf0744400b4c65bed38e5d"
+
+func checkLivePrices(t *testing.T, cfg *config.Config, prices *Prices) {
+	t.Helper()
+	if len(prices.list) < len(cfg.Assets) {
+		t.Errorf("prices count want >%d, got %d", len(cfg.Assets), len(prices.list))
+	}
+	for k, v := range prices.list {
+		t.Logf("%5s: %10s", cfg.Assets[k].Name, v.String())
+	}
+	if prices.minTimestamp == 0 {
+		t.Errorf("minTimestamp is zero")
+	}
+	if prices.data == nil {
+		t.Errorf("data is empty")
+	}
+}
+
+func TestService_GetPrices(t *testing.T) {
+	cfg := config.GetMainMainnetConfig()
+	service := NewService(cfg, newProvider(nil))
+	prices, err := service.GetPrices(context.Background(), Endpoint, "https://evaa.space")
+	if err != nil {
+		t.Fatalf("failed to get prices, err: %s", err)
+	}
+	checkLivePrices(t, cfg, prices)
+}
+
+func TestService_GetPrices_multiEndpoint(t *testing.T) {
+	cfg := config.GetMainMainnetConfig()
+	service := NewService(cfg, newProvider(nil))
+	prices, err := service.GetPrices(context.Background(), "https://evaa.space", "http://localhost", Endpoint)
+	if err != nil {
+		t.Fatalf("failed to get prices, err: %s", err)
+	}
+	checkLivePrices(t, cfg, prices)
+}
+
+func TestService_GetPrices_singleEndpoint(t *testing.T) {
+	cfg := config.GetMainMainnetConfig()
+	endpointProvider := NewSingleEndpointProvider(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go endpointProvider.Update(ctx, "https://evaa.space/api/prices", time.Second)
+	waitForRawData(t, endpointProvider, cfg.Oracles[0].Address, 10*time.Second)
+
+	prices, err := NewService(cfg, endpointProvider).GetPrices(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get prices, err: %s", err)
+	}
+	checkLivePrices(t, cfg, prices)
+}
+
+func TestProvider_GetRawData_live(t *testing.T) {
+	service := newProvider(nil)
+	rawData, err := service.GetRawData(context.Background(), Endpoint, liveOracleAddress)
+	if err != nil {
+		t.Fatalf("failed to GetRawData, err: %s", err)
+	}
+	cfg := config.GetMainMainnetConfig()
+	if err := DefaultPolicy(cfg).check(rawData, cfg.Assets, time.Now()); err != nil {
+		t.Errorf("check want nil, got %s", err)
+	}
+	for k, v := range rawData.Prices() {
+		t.Logf("%s: %10s", k, v.String())
+	}
+}
+
+func TestSingleEndpointProvider_GetRawData_live(t *testing.T) {
+	service := NewSingleEndpointProvider(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go service.Update(ctx, "https://evaa.space/api/prices", time.Second)
+
+	rawData := waitForRawData(t, service, liveOracleAddress, 10*time.Second)
+	cfg := config.GetMainMainnetConfig()
+	if err := DefaultPolicy(cfg).check(rawData, cfg.Assets, time.Now()); err != nil {
+		t.Errorf("check want nil, got %s", err)
+	}
+	for k, v := range rawData.Prices() {
+		t.Logf("%s: %10s", k, v.String())
+	}
+}
@@ -0,0 +1,164 @@
+package price
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+var (
+	ErrOracleOutdated     = errors.New("oracle data is outdated")
+	ErrOracleFromFuture   = errors.New("oracle timestamp is in the future")
+	ErrOracleMissingAsset = errors.New("oracle data misses an asset price")
+	ErrOracleDeviation    = errors.New("oracle price deviates from median")
+)
+
+// Policy defines which oracle data is accepted and how the median prices are selected.
+type Policy struct {
+	// MaxAge is the maximum age of oracle data, the contract rejects older data.
+	MaxAge time.Duration
+	// MaxFutureSkew is how far ahead of the local clock an oracle timestamp may be.
+	// Zero disables the check as the contract does not perform it.
+	MaxFutureSkew time.Duration
+	// Quorum is the minimal number of accepted oracles, zero means config.MinimalOracles.
+	Quorum int
+	// MaxDeviation is the maximum deviation of an oracle price from the median of all valid oracles
+	// in basis points, oracles exceeding it are rejected. Zero disables the check.
+	MaxDeviation uint64
+	// AssetMaxDeviation overrides MaxDeviation per asset ID.
+	AssetMaxDeviation map[string]uint64
+	// UseAllValid calculates medians over all accepted oracles instead of only the newest Quorum ones.
+	UseAllValid bool
+}
+
+// DefaultPolicy returns the policy the master contract enforces.
+func DefaultPolicy(config *config.Config) *Policy {
+	return &Policy{
+		MaxAge: ttlOracleData,
+		Quorum: config.MinimalOracles,
+	}
+}
+
+func (p *Policy) withDefaults(config *config.Config) *Policy {
+	policy := *p
+	if policy.MaxAge <= 0 {
+		policy.MaxAge = ttlOracleData
+	}
+	if policy.Quorum <= 0 {
+		policy.Quorum = config.MinimalOracles
+	}
+	return &policy
+}
+
+func (p *Policy) check(d *RawData, assets map[string]*config.AssetConfig, now time.Time) error {
+	timestamp := time.Unix(d.Timestamp, 0)
+	if now.Sub(timestamp) > p.MaxAge {
+		return fmt.Errorf("%w: timestamp %d", ErrOracleOutdated, d.Timestamp)
+	}
+	if p.MaxFutureSkew > 0 && timestamp.Sub(now) > p.MaxFutureSkew {
+		return fmt.Errorf("%w: timestamp %d", ErrOracleFromFuture, d.Timestamp)
+	}
+
+	prices := d.Prices()
+	for k := range assets {
+		price, ok := prices[k]
+		if !ok || price == nil || price.Sign() != 1 {
+			return fmt.Errorf("%w: %s", ErrOracleMissingAsset, k)
+		}
+	}
+
+	return nil
+}
+
+// selectData applies the deviation limits and the quorum to the valid oracle data. It returns the data
+// to calculate medians over and the reasons the rest of the data was rejected for by oracle ID.
+func (p *Policy) selectData(validPrices []*Data, assets map[string]*config.AssetConfig) ([]*Data, map[uint64]error, error) {
+	rejected := make(map[uint64]error)
+
+	acceptedPrices := slices.Clone(validPrices)
+	if p.MaxDeviation != 0 || len(p.AssetMaxDeviation) != 0 {
+		medians := calculateMedians(validPrices, assets)
+		acceptedPrices = acceptedPrices[:0]
+		for _, data := range validPrices {
+			if err := p.checkDeviation(data.RawData, medians); err != nil {
+				rejected[data.oracleID] = err
+				continue
+			}
+			acceptedPrices = append(acceptedPrices, data)
+		}
+	}
+
+	if len(acceptedPrices) < p.Quorum || len(acceptedPrices) == 0 {
+		return nil, rejected, fmt.Errorf("accepted %d of %d required oracles", len(acceptedPrices), p.Quorum)
+	}
+
+	sort.SliceStable(acceptedPrices, func(i, j int) bool {
+		return acceptedPrices[i].Timestamp > acceptedPrices[j].Timestamp
+	})
+
+	if !p.UseAllValid {
+		for _, data := range acceptedPrices[p.Quorum:] {
+			rejected[data.oracleID] = ErrOracleNotSelected
+		}
+		acceptedPrices = acceptedPrices[:p.Quorum]
+	}
+
+	return acceptedPrices, rejected, nil
+}
+
+// checkDeviation returns an error if any price of the oracle is too far from the given medians.
+func (p *Policy) checkDeviation(d *RawData, medians map[string]*big.Int) error {
+	prices := d.Prices()
+	for asset, median := range medians {
+		maxDeviation := threshold(p.MaxDeviation, p.AssetMaxDeviation, asset)
+		if maxDeviation == 0 || median.Sign() != 1 {
+			continue
+		}
+		if deviation := deviationBps(prices[asset], median); deviation.Cmp(new(big.Int).SetUint64(maxDeviation)) == 1 {
+			return fmt.Errorf("%w: %s by %s bps", ErrOracleDeviation, asset, deviation)
+		}
+	}
+	return nil
+}
+
+// deviationBps returns |price - median| / median in basis points rounded down.
+func deviationBps(price, median *big.Int) *big.Int {
+	diff := new(big.Int).Abs(new(big.Int).Sub(price, median))
+	return mulDiv(diff, big.NewInt(10_000), median)
+}
+
+func calculateMedians(data []*Data, assets map[string]*config.AssetConfig) map[string]*big.Int {
+	medians := make(map[string]*big.Int, len(assets))
+	for k := range assets {
+		values := make([]*big.Int, 0, len(data))
+		for _, d := range data {
+			values = append(values, d.Prices()[k])
+		}
+		medians[k] = median(values)
+	}
+	return medians
+}
+
+func median(values []*big.Int) *big.Int {
+	if len(values) == 0 {
+		return nil
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Cmp(values[j]) == -1
+	})
+
+	medianIndex := len(values) / 2
+	if len(values)%2 == 1 {
+		return values[medianIndex]
+	}
+	return new(big.Int).Div(new(big.Int).Add(values[medianIndex-1], values[medianIndex]), big.NewInt(2))
+}
+
+func mulDiv(x, y, z *big.Int) *big.Int {
+	return new(big.Int).Div(new(big.Int).Mul(x, y), z)
+}
@@ -0,0 +1,155 @@
+package price
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+// oracleStub serves a fixed RawData per oracle address.
+func oracleStub(data map[string]*RawData) Provider {
+	return stubProvider(func(_ context.Context, _ string, address string) (*RawData, error) {
+		rawData, ok := data[address]
+		if !ok {
+			return nil, errors.New("oracle is down")
+		}
+		return rawData, nil
+	})
+}
+
+func TestPolicy(t *testing.T) {
+	cfg := config.GetMainMainnetConfig()
+	now := time.Now().Unix()
+	tonAsset := config.TON.ID()
+
+	tests := []struct {
+		name   string
+		policy *Policy
+		data   []*RawData
+		want   int64
+		err    bool
+	}{
+		{
+			name:   "default uses newest quorum",
+			policy: nil,
+			data: []*RawData{
+				newTestRawData(cfg.Assets, now-100, 100),
+				newTestRawData(cfg.Assets, now-1, 400),
+				newTestRawData(cfg.Assets, now-2, 300),
+				newTestRawData(cfg.Assets, now-3, 200),
+			},
+			want: 300,
+		},
+		{
+			name:   "outdated oracles break quorum",
+			policy: nil,
+			data: []*RawData{
+				newTestRawData(cfg.Assets, now-1, 100),
+				newTestRawData(cfg.Assets, now-121, 100),
+				newTestRawData(cfg.Assets, now-1, 100),
+				newTestRawData(cfg.Assets, now-500, 100),
+			},
+			err: true,
+		},
+		{
+			name:   "custom max age",
+			policy: &Policy{MaxAge: 10 * time.Second, Quorum: 2},
+			data: []*RawData{
+				newTestRawData(cfg.Assets, now-1, 100),
+				newTestRawData(cfg.Assets, now-20, 1000),
+				newTestRawData(cfg.Assets, now-2, 200),
+				newTestRawData(cfg.Assets, now-30, 1000),
+			},
+			want: 150,
+		},
+		{
+			name:   "future timestamps",
+			policy: &Policy{MaxFutureSkew: 5 * time.Second},
+			data: []*RawData{
+				newTestRawData(cfg.Assets, now+60, 1000),
+				newTestRawData(cfg.Assets, now-1, 100),
+				newTestRawData(cfg.Assets, now-2, 200),
+				newTestRawData(cfg.Assets, now-3, 300),
+			},
+			want: 200,
+		},
+		{
+			name:   "deviating oracle is rejected",
+			policy: &Policy{MaxDeviation: 500},
+			data: []*RawData{
+				newTestRawData(cfg.Assets, now-1, 2000),
+				newTestRawData(cfg.Assets, now-2, 1000),
+				newTestRawData(cfg.Assets, now-3, 1010),
+				newTestRawData(cfg.Assets, now-4, 990),
+			},
+			want: 1000,
+		},
+		{
+			name:   "asset deviation override",
+			policy: &Policy{MaxDeviation: 500, AssetMaxDeviation: map[string]uint64{tonAsset: 50}},
+			data: []*RawData{
+				newTestRawData(cfg.Assets, now-1, 2000),
+				newTestRawData(cfg.Assets, now-2, 1000),
+				newTestRawData(cfg.Assets, now-3, 1010),
+				newTestRawData(cfg.Assets, now-4, 990),
+			},
+			err: true,
+		},
+		{
+			name:   "all valid oracles",
+			policy: &Policy{UseAllValid: true},
+			data: []*RawData{
+				newTestRawData(cfg.Assets, now-1, 100),
+				newTestRawData(cfg.Assets, now-2, 200),
+				newTestRawData(cfg.Assets, now-3, 300),
+				newTestRawData(cfg.Assets, now-4, 400),
+			},
+			want: 250,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := make(map[string]*RawData, len(tt.data))
+			for i, rawData := range tt.data {
+				data[cfg.Oracles[i].Address] = rawData
+			}
+			prices, err := NewService(cfg, oracleStub(data)).SetPolicy(tt.policy).GetPrices(context.Background())
+			if tt.err {
+				if err == nil {
+					t.Fatalf("GetPrices want error, got TON price %s", prices.Get(tonAsset))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get prices, err: %s", err)
+			}
+			if got := prices.Get(tonAsset); got.Cmp(big.NewInt(tt.want)) != 0 {
+				t.Errorf("TON price want %d, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPolicy_check(t *testing.T) {
+	cfg := config.GetMainMainnetConfig()
+	policy := DefaultPolicy(cfg)
+	now := time.Now()
+
+	rawData := newTestRawData(cfg.Assets, now.Unix(), 100)
+	if err := policy.check(rawData, cfg.Assets, now); err != nil {
+		t.Errorf("check want nil, got %s", err)
+	}
+	if err := policy.check(rawData, cfg.Assets, now.Add(ttlOracleData+time.Second)); !errors.Is(err, ErrOracleOutdated) {
+		t.Errorf("check want %s, got %v", ErrOracleOutdated, err)
+	}
+
+	assets := map[string]*config.AssetConfig{config.TON.ID(): cfg.Assets[config.TON.ID()]}
+	rawData = newTestRawData(assets, now.Unix(), 100)
+	if err := policy.check(rawData, cfg.Assets, now); !errors.Is(err, ErrOracleMissingAsset) {
+		t.Errorf("check want %s, got %v", ErrOracleMissingAsset, err)
+	}
+}
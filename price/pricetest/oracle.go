@@ -0,0 +1,110 @@
+// Package pricetest provides fake EVAA oracles for hermetic tests of price consumers.
+package pricetest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+// Oracle is a fake oracle with its own ed25519 key.
+type Oracle struct {
+	ID         uint64
+	Address    string
+	PublicKey  ed25519.PublicKey
+	privateKey ed25519.PrivateKey
+}
+
+// NewOracle generates a fresh key, the NFT address is derived from the public key.
+func NewOracle(id uint64) (*Oracle, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key, err: %w", err)
+	}
+	address := sha256.Sum256(publicKey)
+	return &Oracle{
+		ID:         id,
+		Address:    "0x" + hex.EncodeToString(address[:]),
+		PublicKey:  publicKey,
+		privateKey: privateKey,
+	}, nil
+}
+
+// NewOracles generates count oracles with IDs starting from zero.
+func NewOracles(count int) ([]*Oracle, error) {
+	oracles := make([]*Oracle, 0, count)
+	for i := 0; i < count; i++ {
+		oracle, err := NewOracle(uint64(i))
+		if err != nil {
+			return nil, err
+		}
+		oracles = append(oracles, oracle)
+	}
+	return oracles, nil
+}
+
+// Sign packs and signs the prices by asset ID the way EVAA oracles do
+// and returns them as NFT feature data accepted by price.Parse.
+func (o *Oracle) Sign(prices map[string]*big.Int, timestamp int64) (string, error) {
+	dict := cell.NewDict(256)
+	for asset, price := range prices {
+		id, ok := new(big.Int).SetString(asset, 10)
+		if !ok {
+			return "", fmt.Errorf("invalid asset id %s", asset)
+		}
+		if err := dict.SetIntKey(id, cell.BeginCell().MustStoreBigCoins(price).EndCell()); err != nil {
+			return "", fmt.Errorf("failed to store price of %s, err: %w", asset, err)
+		}
+	}
+
+	packedPrices := cell.BeginCell().
+		MustStoreUInt(uint64(timestamp), 32).
+		MustStoreMaybeRef(dict.AsCell()).
+		EndCell()
+
+	data, err := json.Marshal(struct {
+		Status       string `json:"status"`
+		Timestamp    int64  `json:"timestamp"`
+		PackedPrices string `json:"packedPrices"`
+		Signature    string `json:"signature"`
+		PublicKey    string `json:"publicKey"`
+	}{
+		Status:       "ok",
+		Timestamp:    timestamp,
+		PackedPrices: hex.EncodeToString(packedPrices.ToBOC()),
+		Signature:    hex.EncodeToString(ed25519.Sign(o.privateKey, packedPrices.Hash())),
+		PublicKey:    hex.EncodeToString(o.PublicKey),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal data, err: %w", err)
+	}
+	return "0x" + hex.EncodeToString(data), nil
+}
+
+// Config returns the main pool config using the given oracles with a majority quorum.
+func Config(oracles ...*Oracle) *config.Config {
+	cfg := config.GetMainMainnetConfig()
+	cfg.Oracles = make([]*config.OracleNFT, 0, len(oracles))
+	for _, oracle := range oracles {
+		cfg.Oracles = append(cfg.Oracles, &config.OracleNFT{ID: oracle.ID, Address: oracle.Address})
+	}
+	cfg.MinimalOracles = len(oracles)/2 + 1
+	return cfg
+}
+
+// Prices returns the same price for every asset of the config.
+func Prices(cfg *config.Config, price *big.Int) map[string]*big.Int {
+	prices := make(map[string]*big.Int, len(cfg.Assets))
+	for asset := range cfg.Assets {
+		prices[asset] = new(big.Int).Set(price)
+	}
+	return prices
+}
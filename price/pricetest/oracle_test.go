@@ -0,0 +1,68 @@
+package pricetest
+
+import (
+	"crypto/ed25519"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+	"github.com/evaafi/evaa-go-sdk/price"
+)
+
+func TestOracle_Sign(t *testing.T) {
+	oracle, err := NewOracle(7)
+	if err != nil {
+		t.Fatalf("failed to create oracle, err: %s", err)
+	}
+	cfg := Config(oracle)
+	prices := Prices(cfg, big.NewInt(4_575_000_000))
+	timestamp := time.Now().Unix()
+
+	feature, err := oracle.Sign(prices, timestamp)
+	if err != nil {
+		t.Fatalf("failed to sign prices, err: %s", err)
+	}
+	rawData, err := price.Parse(feature)
+	if err != nil {
+		t.Fatalf("failed to parse feature, err: %s", err)
+	}
+	if rawData.Timestamp != timestamp {
+		t.Errorf("Timestamp want %d, got %d", timestamp, rawData.Timestamp)
+	}
+	if !ed25519.PublicKey(rawData.PubKey).Equal(oracle.PublicKey) {
+		t.Errorf("PubKey want %x, got %x", oracle.PublicKey, rawData.PubKey)
+	}
+	for asset, want := range prices {
+		if got := rawData.Prices()[asset]; got == nil || got.Cmp(want) != 0 {
+			t.Errorf("price of %s want %s, got %v", asset, want, got)
+		}
+	}
+
+	signed := cell.BeginCell().
+		MustStoreUInt(uint64(rawData.Timestamp), 32).
+		MustStoreMaybeRef(rawData.PricesDict.AsCell()).
+		EndCell()
+	if !ed25519.Verify(oracle.PublicKey, signed.Hash(), rawData.Signature) {
+		t.Errorf("signature is not valid")
+	}
+}
+
+func TestConfig(t *testing.T) {
+	oracles, err := NewOracles(4)
+	if err != nil {
+		t.Fatalf("failed to create oracles, err: %s", err)
+	}
+	cfg := Config(oracles...)
+	if len(cfg.Oracles) != 4 || cfg.MinimalOracles != 3 {
+		t.Errorf("oracles want %d/%d, got %d/%d", 4, 3, len(cfg.Oracles), cfg.MinimalOracles)
+	}
+	if cfg.Oracles[2].Address != oracles[2].Address {
+		t.Errorf("oracle address want %s, got %s", oracles[2].Address, cfg.Oracles[2].Address)
+	}
+	if _, ok := cfg.Assets[config.TON.ID()]; !ok {
+		t.Errorf("config should contain main pool assets")
+	}
+}
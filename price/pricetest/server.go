@@ -0,0 +1,166 @@
+package pricetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	OutputsPath = "/api/indexer/v1/outputs/nft/"
+	CorePath    = "/api/core/v2/outputs/"
+	// PricesPath serves the JSON map of oracle address to feature data read by price.SingleEndpointProvider.
+	PricesPath = "/api/prices"
+)
+
+// Server serves signed oracle data through the IOTA indexer and core APIs and the single endpoint JSON map.
+type Server struct {
+	*httptest.Server
+
+	mtx      sync.RWMutex
+	oracles  map[string]*Oracle
+	features map[string]string
+	failures map[string]int
+	delay    time.Duration
+}
+
+// NewServer starts a server for the oracles, it must be closed by the caller.
+func NewServer(oracles ...*Oracle) *Server {
+	s := &Server{
+		oracles:  make(map[string]*Oracle, len(oracles)),
+		features: make(map[string]string, len(oracles)),
+		failures: make(map[string]int),
+	}
+	for _, oracle := range oracles {
+		s.oracles[oracle.Address] = oracle
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// SetPrices makes every oracle publish the prices signed with the timestamp.
+func (s *Server) SetPrices(prices map[string]*big.Int, timestamp int64) error {
+	s.mtx.RLock()
+	oracles := make([]*Oracle, 0, len(s.oracles))
+	for _, oracle := range s.oracles {
+		oracles = append(oracles, oracle)
+	}
+	s.mtx.RUnlock()
+
+	for _, oracle := range oracles {
+		if err := s.SetOraclePrices(oracle, prices, timestamp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetOraclePrices makes a single oracle publish the prices signed with the timestamp.
+func (s *Server) SetOraclePrices(oracle *Oracle, prices map[string]*big.Int, timestamp int64) error {
+	feature, err := oracle.Sign(prices, timestamp)
+	if err != nil {
+		return fmt.Errorf("failed to sign oracle %d prices, err: %w", oracle.ID, err)
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.features[oracle.Address] = feature
+	return nil
+}
+
+// SetFailure makes requests for the oracle address fail with the status code, zero restores it.
+func (s *Server) SetFailure(address string, statusCode int) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if statusCode == 0 {
+		delete(s.failures, address)
+		return
+	}
+	s.failures[address] = statusCode
+}
+
+// SetDelay delays every response, a request is abandoned as soon as its context is done.
+func (s *Server) SetDelay(delay time.Duration) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.delay = delay
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mtx.RLock()
+	delay := s.delay
+	s.mtx.RUnlock()
+
+	if delay > 0 {
+		t := time.NewTimer(delay)
+		defer t.Stop()
+		select {
+		case <-r.Context().Done():
+			return
+		case <-t.C:
+		}
+	}
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	switch {
+	case strings.HasPrefix(r.URL.Path, OutputsPath):
+		address := strings.TrimPrefix(r.URL.Path, OutputsPath)
+		if !s.available(w, address) {
+			return
+		}
+		writeJSON(w, map[string][]string{"items": {address}})
+	case strings.HasPrefix(r.URL.Path, CorePath):
+		address := strings.TrimPrefix(r.URL.Path, CorePath)
+		if !s.available(w, address) {
+			return
+		}
+		var resp struct {
+			Output struct {
+				Features []struct {
+					Data string `json:"data"`
+				} `json:"features"`
+			} `json:"output"`
+		}
+		resp.Output.Features = append(resp.Output.Features, struct {
+			Data string `json:"data"`
+		}{Data: s.features[address]})
+		writeJSON(w, resp)
+	case r.URL.Path == PricesPath:
+		features := make(map[string]string, len(s.features))
+		for address, feature := range s.features {
+			if _, failed := s.failures[address]; !failed {
+				features[address] = feature
+			}
+		}
+		writeJSON(w, features)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) available(w http.ResponseWriter, address string) bool {
+	if statusCode, ok := s.failures[address]; ok {
+		w.WriteHeader(statusCode)
+		return false
+	}
+	if _, ok := s.features[address]; !ok {
+		writeJSON(w, map[string][]string{"items": {}})
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
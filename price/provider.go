@@ -6,7 +6,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/evaafi/evaa-go-sdk/config"
 	"github.com/xssnick/tonutils-go/tvm/cell"
 	"math/big"
 	"net/http"
@@ -61,6 +60,9 @@ func (p *provider) getOutputID(ctx context.Context, baseURL string, address stri
 		return "", fmt.Errorf("error getting outputs: %w", err)
 	}
 	defer outputsResp.Body.Close()
+	if err := checkStatus(outputsResp); err != nil {
+		return "", fmt.Errorf("error getting outputs: %w", err)
+	}
 
 	var outputIDResp struct {
 		Items []string `json:"items"`
@@ -84,6 +86,9 @@ func (p *provider) getFeature(ctx context.Context, baseURL, outputID string) (fe
 		return "", fmt.Errorf("error getting core data: %w", err)
 	}
 	defer coreResp.Body.Close()
+	if err := checkStatus(coreResp); err != nil {
+		return "", fmt.Errorf("error getting core data: %w", err)
+	}
 
 	var resData struct {
 		Output struct {
@@ -102,6 +107,28 @@ func (p *provider) getFeature(ctx context.Context, baseURL, outputID string) (fe
 	return resData.Output.Features[0].Data, nil
 }
 
+// StatusError is returned when an endpoint responds with a non-2xx status code.
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %s", e.Status)
+}
+
+// Temporary reports whether the request is worth retrying.
+func (e *StatusError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	return nil
+}
+
 func Parse(data string) (*RawData, error) {
 	if len(data)%2 == 1 {
 		return nil, errors.New("invalid price data")
@@ -196,23 +223,3 @@ func (d *RawData) Prices() map[string]*big.Int {
 }
 
 const ttlOracleData = 120 * time.Second
-
-func (d *RawData) verify(assets map[string]*config.AssetConfig) bool {
-	if time.Since(time.Unix(d.Timestamp, 0)) > ttlOracleData {
-		return false
-	}
-
-	prices := d.Prices()
-	if len(prices) < len(assets) {
-		return false
-	}
-
-	for k, _ := range assets {
-		price, ok := prices[k]
-		if !ok || price == nil || price.Sign() != 1 {
-			return false
-		}
-	}
-
-	return true
-}
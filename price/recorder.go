@@ -0,0 +1,251 @@
+package price
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+var ErrNoRecords = errors.New("no recorded prices")
+
+// Record is a set of median prices as they were seen by the protocol.
+type Record struct {
+	// Timestamp is the newest oracle timestamp of the set, the moment the prices became available.
+	Timestamp int64
+	Prices    map[string]*big.Int
+}
+
+// Recorder keeps the history of accepted oracle data and the resulting median prices in memory.
+// It is safe for concurrent use.
+type Recorder struct {
+	config        *config.Config
+	policy        *Policy
+	retention     time.Duration
+	proofSkeleton *cell.ProofSkeleton
+
+	mtx     sync.RWMutex
+	oracles map[uint64][]*Data
+	records []*Record
+}
+
+// NewRecorder creates a recorder which rebuilds prices according to the policy
+// and forgets data older than retention, zero retention keeps everything.
+func NewRecorder(config *config.Config, policy *Policy, retention time.Duration) *Recorder {
+	if policy == nil {
+		policy = DefaultPolicy(config)
+	}
+	proofSkeleton := cell.CreateProofSkeleton()
+	proofSkeleton.SetRecursive()
+	return &Recorder{
+		config:        config,
+		policy:        policy.withDefaults(config),
+		retention:     retention,
+		proofSkeleton: proofSkeleton,
+		oracles:       make(map[uint64][]*Data),
+	}
+}
+
+// Record stores the median prices along with the oracle data they were calculated from.
+// Synthetic prices are ignored.
+func (r *Recorder) Record(prices *Prices) {
+	if prices.Synthetic() {
+		return
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	record := &Record{Prices: prices.list}
+	for _, data := range prices.oracles {
+		record.Timestamp = max(record.Timestamp, data.Timestamp)
+		r.recordOracle(data)
+	}
+
+	i := sort.Search(len(r.records), func(i int) bool {
+		return r.records[i].Timestamp > record.Timestamp
+	})
+	if i > 0 && r.records[i-1].Timestamp == record.Timestamp {
+		r.records[i-1] = record
+	} else {
+		r.records = append(r.records, nil)
+		copy(r.records[i+1:], r.records[i:])
+		r.records[i] = record
+	}
+
+	r.prune()
+}
+
+// RecordReport stores the data of every oracle which passed the policy checks, whether it was used or not.
+func (r *Recorder) RecordReport(report *Report) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	for id, oracle := range report.Oracles {
+		if oracle.valid() {
+			r.recordOracle(&Data{RawData: oracle.Data, oracleID: id})
+		}
+	}
+	r.prune()
+}
+
+// RecordOracle stores a single oracle payload.
+func (r *Recorder) RecordOracle(oracleID uint64, data *RawData) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.recordOracle(&Data{RawData: data, oracleID: oracleID})
+	r.prune()
+}
+
+func (r *Recorder) recordOracle(data *Data) {
+	// RawData decodes its prices on first use, decoding under the write lock keeps Rebuild read-only
+	data.Prices()
+	history := r.oracles[data.oracleID]
+	i := sort.Search(len(history), func(i int) bool {
+		return history[i].Timestamp >= data.Timestamp
+	})
+	if i < len(history) && history[i].Timestamp == data.Timestamp {
+		return
+	}
+	history = append(history, nil)
+	copy(history[i+1:], history[i:])
+	history[i] = data
+	r.oracles[data.oracleID] = history
+}
+
+func (r *Recorder) prune() {
+	if r.retention <= 0 {
+		return
+	}
+	threshold := time.Now().Add(-r.retention).Unix()
+
+	// the latest record before the threshold is kept as it is still in effect at the threshold
+	i := sort.Search(len(r.records), func(i int) bool {
+		return r.records[i].Timestamp > threshold
+	})
+	if i > 1 {
+		r.records = append(r.records[:0:0], r.records[i-1:]...)
+	}
+
+	for id, history := range r.oracles {
+		i := sort.Search(len(history), func(i int) bool {
+			return history[i].Timestamp >= threshold
+		})
+		if i == len(history) {
+			delete(r.oracles, id)
+		} else if i > 0 {
+			r.oracles[id] = append(history[:0:0], history[i:]...)
+		}
+	}
+}
+
+// Records returns the records made available within [from, to].
+func (r *Recorder) Records(from, to time.Time) []*Record {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	i := sort.Search(len(r.records), func(i int) bool {
+		return r.records[i].Timestamp >= from.Unix()
+	})
+	j := sort.Search(len(r.records), func(j int) bool {
+		return r.records[j].Timestamp > to.Unix()
+	})
+	return append([]*Record(nil), r.records[i:j]...)
+}
+
+// PriceAt returns the median price of the asset which was in effect at the moment.
+func (r *Recorder) PriceAt(asset string, at time.Time) (*big.Int, bool) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	i := r.recordAt(at.Unix())
+	if i < 0 {
+		return nil, false
+	}
+	price, ok := r.records[i].Prices[asset]
+	return price, ok
+}
+
+// TWAP returns the time-weighted average median price of the asset over [from, to).
+// If there is no record before from, the window starts at the first recorded price.
+func (r *Recorder) TWAP(asset string, from, to time.Time) (*big.Int, error) {
+	if !to.After(from) {
+		return nil, fmt.Errorf("invalid window from %s to %s", from, to)
+	}
+
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	start, end := from.Unix(), to.Unix()
+	i := r.recordAt(start)
+	if i < 0 {
+		i = 0
+		if len(r.records) == 0 || r.records[0].Timestamp >= end {
+			return nil, ErrNoRecords
+		}
+		start = r.records[0].Timestamp
+	}
+
+	sum, duration := new(big.Int), int64(0)
+	for ; i < len(r.records) && r.records[i].Timestamp < end; i++ {
+		periodStart := max(start, r.records[i].Timestamp)
+		periodEnd := end
+		if i+1 < len(r.records) {
+			periodEnd = min(end, r.records[i+1].Timestamp)
+		}
+
+		price, ok := r.records[i].Prices[asset]
+		if !ok || periodEnd <= periodStart {
+			continue
+		}
+		sum.Add(sum, new(big.Int).Mul(price, big.NewInt(periodEnd-periodStart)))
+		duration += periodEnd - periodStart
+	}
+	if duration == 0 {
+		return nil, ErrNoRecords
+	}
+	return sum.Div(sum, big.NewInt(duration)), nil
+}
+
+// Rebuild selects the latest recorded data of every oracle valid at the moment according to the policy
+// and packs it into prices exactly as GetPrices would have done.
+func (r *Recorder) Rebuild(at time.Time) (*Prices, error) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	validPrices := make([]*Data, 0, len(r.oracles))
+	for _, history := range r.oracles {
+		i := sort.Search(len(history), func(i int) bool {
+			return history[i].Timestamp > at.Unix()
+		})
+		if i == 0 {
+			continue
+		}
+		if data := history[i-1]; r.policy.check(data.RawData, r.config.Assets, at) == nil {
+			validPrices = append(validPrices, data)
+		}
+	}
+	sort.Slice(validPrices, func(i, j int) bool {
+		return validPrices[i].oracleID < validPrices[j].oracleID
+	})
+
+	acceptedPrices, _, err := r.policy.selectData(validPrices, r.config.Assets)
+	if err != nil {
+		return nil, fmt.Errorf("%w at %d, %w", ErrNoRecords, at.Unix(), err)
+	}
+	return packPrices(r.config.Assets, acceptedPrices, r.proofSkeleton)
+}
+
+// recordAt returns the index of the latest record made available at or before ts, -1 if there is none.
+func (r *Recorder) recordAt(ts int64) int {
+	return sort.Search(len(r.records), func(i int) bool {
+		return r.records[i].Timestamp > ts
+	}) - 1
+}
@@ -0,0 +1,133 @@
+package price
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+func TestRecorder(t *testing.T) {
+	cfg := config.GetMainMainnetConfig()
+	tonAsset := config.TON.ID()
+	start := time.Now().Add(-time.Minute).Truncate(time.Second)
+
+	data := map[string]*RawData{}
+	service := NewService(cfg, oracleStub(data))
+	recorder := NewRecorder(cfg, nil, 0)
+
+	var recorded []*Prices
+	for i, price := range []int64{100, 200, 400} {
+		ts := start.Add(time.Duration(i) * 10 * time.Second).Unix()
+		for j, oracle := range cfg.Oracles {
+			data[oracle.Address] = newTestRawData(cfg.Assets, ts-int64(j), price)
+		}
+		prices, report, err := service.GetPricesDetailed(context.Background())
+		if err != nil {
+			t.Fatalf("failed to get prices, err: %s", err)
+		}
+		recorder.Record(prices)
+		recorder.RecordReport(report)
+		recorded = append(recorded, prices)
+	}
+
+	if _, ok := recorder.PriceAt(tonAsset, start.Add(-time.Second)); ok {
+		t.Errorf("PriceAt before the first record want none")
+	}
+	for _, tt := range []struct {
+		at   time.Duration
+		want int64
+	}{
+		{0, 100},
+		{9 * time.Second, 100},
+		{10 * time.Second, 200},
+		{25 * time.Second, 400},
+	} {
+		got, ok := recorder.PriceAt(tonAsset, start.Add(tt.at))
+		if !ok || got.Cmp(big.NewInt(tt.want)) != 0 {
+			t.Errorf("PriceAt +%s want %d, got %v", tt.at, tt.want, got)
+		}
+	}
+
+	// 5s of 100, 10s of 200 and 5s of 400
+	twap, err := recorder.TWAP(tonAsset, start.Add(5*time.Second), start.Add(25*time.Second))
+	if err != nil {
+		t.Fatalf("failed to calculate TWAP, err: %s", err)
+	}
+	if twap.Cmp(big.NewInt(225)) != 0 {
+		t.Errorf("TWAP want %d, got %s", 225, twap)
+	}
+	twap, err = recorder.TWAP(tonAsset, start.Add(-time.Hour), start.Add(20*time.Second))
+	if err != nil {
+		t.Fatalf("failed to calculate TWAP, err: %s", err)
+	}
+	if twap.Cmp(big.NewInt(150)) != 0 {
+		t.Errorf("TWAP from before the first record want %d, got %s", 150, twap)
+	}
+	if _, err := recorder.TWAP(tonAsset, start.Add(-time.Hour), start.Add(-time.Minute)); !errors.Is(err, ErrNoRecords) {
+		t.Errorf("TWAP without records want %s, got %v", ErrNoRecords, err)
+	}
+
+	if records := recorder.Records(start, start.Add(10*time.Second)); len(records) != 2 {
+		t.Errorf("Records want %d, got %d", 2, len(records))
+	}
+
+	for i, prices := range recorded {
+		rebuilt, err := recorder.Rebuild(start.Add(time.Duration(i)*10*time.Second + 5*time.Second))
+		if err != nil {
+			t.Fatalf("failed to rebuild prices, err: %s", err)
+		}
+		if rebuilt.Get(tonAsset).Cmp(prices.Get(tonAsset)) != 0 {
+			t.Errorf("rebuilt TON price want %s, got %s", prices.Get(tonAsset), rebuilt.Get(tonAsset))
+		}
+		if !bytes.Equal(rebuilt.Data().Hash(), prices.Data().Hash()) {
+			t.Errorf("rebuilt data %d differs from the original one", i)
+		}
+	}
+	if _, err := recorder.Rebuild(start.Add(time.Hour)); !errors.Is(err, ErrNoRecords) {
+		t.Errorf("Rebuild after oracle TTL want %s, got %v", ErrNoRecords, err)
+	}
+}
+
+func TestRecorder_retention(t *testing.T) {
+	cfg := config.GetMainMainnetConfig()
+	recorder := NewRecorder(cfg, nil, time.Minute)
+	now := time.Now()
+
+	for _, ts := range []time.Time{now.Add(-time.Hour), now.Add(-30 * time.Minute), now.Add(-time.Second)} {
+		for _, oracle := range cfg.Oracles {
+			recorder.RecordOracle(oracle.ID, newTestRawData(cfg.Assets, ts.Unix(), 100))
+		}
+	}
+	for id, history := range recorder.oracles {
+		if len(history) != 1 {
+			t.Errorf("oracle %d history want %d, got %d", id, 1, len(history))
+		}
+	}
+}
+
+func TestRecorder_concurrentRebuild(t *testing.T) {
+	cfg := config.GetMainMainnetConfig()
+	recorder := NewRecorder(cfg, nil, 0)
+	now := time.Now()
+	for _, oracle := range cfg.Oracles {
+		recorder.RecordOracle(oracle.ID, newTestRawData(cfg.Assets, now.Unix(), 100))
+	}
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := recorder.Rebuild(now); err != nil {
+				t.Errorf("failed to rebuild prices, err: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,103 @@
+package price
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+var (
+	ErrOracleUnreachable = errors.New("oracle data is unreachable")
+	ErrOracleNotSelected = errors.New("oracle data is valid but older than the selected quorum")
+)
+
+// Report describes how the oracle data of a GetPricesDetailed call was obtained and judged.
+type Report struct {
+	// Oracles holds a report for every configured oracle by its ID.
+	Oracles map[uint64]*OracleReport
+	// Spread holds the dispersion of every asset price across the valid oracles by asset ID.
+	Spread map[string]*AssetSpread
+	// Alerts holds the anomalies found by the service detector, if one is set.
+	Alerts []*Alert
+}
+
+type OracleReport struct {
+	OracleID uint64
+	// Endpoint is the endpoint the data was received from, empty if none has answered.
+	Endpoint string
+	Latency  time.Duration
+	// Timestamp is the oracle data timestamp, zero if it was not received.
+	Timestamp int64
+	Accepted  bool
+	// Reason explains why the oracle was not accepted, it wraps one of the ErrOracle* errors.
+	Reason error
+	Data   *RawData
+}
+
+func newOracleReport(oracleID uint64, endpoint string, latency time.Duration, data *RawData, err error) *OracleReport {
+	report := &OracleReport{OracleID: oracleID, Endpoint: endpoint, Latency: latency, Data: data}
+	if err != nil {
+		report.Reason = fmt.Errorf("%w: %w", ErrOracleUnreachable, err)
+		return report
+	}
+	report.Timestamp = data.Timestamp
+	return report
+}
+
+func (r *OracleReport) reject(reason error) {
+	r.Accepted = false
+	r.Reason = reason
+}
+
+type AssetSpread struct {
+	Min    *big.Int
+	Max    *big.Int
+	Median *big.Int
+	// Deviation is (Max - Min) / Median in basis points.
+	Deviation *big.Int
+}
+
+// err joins the rejection reasons of all oracles ordered by oracle ID.
+func (r *Report) err() error {
+	ids := make([]uint64, 0, len(r.Oracles))
+	for id := range r.Oracles {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	errs := make([]error, 0, len(ids))
+	for _, id := range ids {
+		if reason := r.Oracles[id].Reason; reason != nil {
+			errs = append(errs, fmt.Errorf("oracle %d: %w", id, reason))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func calculateSpread(data []*Data, assets map[string]*config.AssetConfig) map[string]*AssetSpread {
+	spread := make(map[string]*AssetSpread, len(assets))
+	if len(data) == 0 {
+		return spread
+	}
+
+	medians := calculateMedians(data, assets)
+	for k := range assets {
+		s := &AssetSpread{Median: medians[k]}
+		for _, d := range data {
+			price := d.Prices()[k]
+			if s.Min == nil || price.Cmp(s.Min) == -1 {
+				s.Min = price
+			}
+			if s.Max == nil || price.Cmp(s.Max) == 1 {
+				s.Max = price
+			}
+		}
+		s.Deviation = mulDiv(new(big.Int).Sub(s.Max, s.Min), big.NewInt(10_000), s.Median)
+		spread[k] = s
+	}
+	return spread
+}
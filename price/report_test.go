@@ -0,0 +1,94 @@
+package price
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+func TestService_GetPricesDetailed(t *testing.T) {
+	cfg := config.GetMainMainnetConfig()
+	now := time.Now().Unix()
+	tonAsset := config.TON.ID()
+
+	service := NewService(cfg, oracleStub(map[string]*RawData{
+		cfg.Oracles[0].Address: newTestRawData(cfg.Assets, now-4, 100),
+		cfg.Oracles[1].Address: newTestRawData(cfg.Assets, now-1, 200),
+		cfg.Oracles[2].Address: newTestRawData(cfg.Assets, now-2, 300),
+		cfg.Oracles[3].Address: newTestRawData(cfg.Assets, now-3, 400),
+	}))
+	prices, report, err := service.GetPricesDetailed(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get prices, err: %s", err)
+	}
+	if got := prices.Get(tonAsset); got.Cmp(big.NewInt(300)) != 0 {
+		t.Errorf("TON price want %d, got %s", 300, got)
+	}
+	if len(report.Oracles) != len(cfg.Oracles) {
+		t.Fatalf("oracle reports want %d, got %d", len(cfg.Oracles), len(report.Oracles))
+	}
+	for id, oracle := range report.Oracles {
+		if oracle.Endpoint != Endpoint {
+			t.Errorf("oracle %d Endpoint want %s, got %s", id, Endpoint, oracle.Endpoint)
+		}
+		if oracle.Timestamp == 0 {
+			t.Errorf("oracle %d Timestamp is zero", id)
+		}
+	}
+	if oracle := report.Oracles[0]; oracle.Accepted || !errors.Is(oracle.Reason, ErrOracleNotSelected) {
+		t.Errorf("oracle 0 want rejected with %s, got %v", ErrOracleNotSelected, oracle.Reason)
+	}
+	for _, id := range []uint64{1, 2, 3} {
+		if oracle := report.Oracles[id]; !oracle.Accepted || oracle.Reason != nil {
+			t.Errorf("oracle %d want accepted, got %v", id, oracle.Reason)
+		}
+	}
+
+	spread := report.Spread[tonAsset]
+	if spread.Min.Cmp(big.NewInt(100)) != 0 || spread.Max.Cmp(big.NewInt(400)) != 0 || spread.Median.Cmp(big.NewInt(250)) != 0 {
+		t.Errorf("spread want 100/400/250, got %s/%s/%s", spread.Min, spread.Max, spread.Median)
+	}
+	if spread.Deviation.Cmp(big.NewInt(12_000)) != 0 {
+		t.Errorf("Deviation want %d, got %s", 12_000, spread.Deviation)
+	}
+}
+
+func TestService_GetPricesDetailed_rejected(t *testing.T) {
+	cfg := config.GetMainMainnetConfig()
+	now := time.Now().Unix()
+	tonOnly := map[string]*config.AssetConfig{config.TON.ID(): cfg.Assets[config.TON.ID()]}
+
+	service := NewService(cfg, oracleStub(map[string]*RawData{
+		cfg.Oracles[1].Address: newTestRawData(cfg.Assets, now-600, 100),
+		cfg.Oracles[2].Address: newTestRawData(tonOnly, now, 100),
+		cfg.Oracles[3].Address: newTestRawData(cfg.Assets, now, 100),
+	}))
+	prices, report, err := service.GetPricesDetailed(context.Background())
+	if err == nil {
+		t.Fatalf("GetPricesDetailed want error, got %v", prices)
+	}
+	if report == nil {
+		t.Fatalf("report should be returned on failure")
+	}
+
+	for id, want := range map[uint64]error{
+		0: ErrOracleUnreachable,
+		1: ErrOracleOutdated,
+		2: ErrOracleMissingAsset,
+	} {
+		oracle := report.Oracles[id]
+		if oracle.Accepted || !errors.Is(oracle.Reason, want) {
+			t.Errorf("oracle %d want rejected with %s, got %v", id, want, oracle.Reason)
+		}
+		if !errors.Is(err, want) {
+			t.Errorf("error should wrap %s, got %s", want, err)
+		}
+	}
+	if oracle := report.Oracles[3]; oracle.Reason != nil {
+		t.Errorf("oracle 3 want valid, got %s", oracle.Reason)
+	}
+}
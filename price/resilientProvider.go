@@ -0,0 +1,316 @@
+package price
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/url"
+	"sync"
+	"time"
+)
+
+var _ Provider = (*ResilientProvider)(nil)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// ResilientConfig configures retries, circuit breaking and hedging of a ResilientProvider.
+type ResilientConfig struct {
+	// Endpoints are queried after the baseURL passed to GetRawData, in order.
+	Endpoints []string
+	// MaxRetries is the number of additional attempts per endpoint for temporary failures.
+	MaxRetries int
+	// BackoffBase is the delay before the first retry, it doubles on every next one up to BackoffMax.
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// AttemptTimeout limits a single request, zero means no limit besides ctx.
+	AttemptTimeout time.Duration
+	// BreakerThreshold is the number of consecutive failures after which an endpoint is skipped
+	// for BreakerCooldown. Zero disables circuit breaking.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	// HedgeDelay is how long to wait for an endpoint before querying the next one in parallel.
+	// Zero queries the next endpoint only after the previous one has failed.
+	HedgeDelay time.Duration
+}
+
+func DefaultResilientConfig() *ResilientConfig {
+	return &ResilientConfig{
+		MaxRetries:       2,
+		BackoffBase:      100 * time.Millisecond,
+		BackoffMax:       time.Second,
+		AttemptTimeout:   5 * time.Second,
+		BreakerThreshold: 5,
+		BreakerCooldown:  30 * time.Second,
+		HedgeDelay:       500 * time.Millisecond,
+	}
+}
+
+// ResilientProvider wraps a Provider with retries, per-endpoint circuit breakers and hedged requests.
+// Since it fans out across endpoints by itself, pass a single endpoint to Service.GetPrices.
+type ResilientProvider struct {
+	provider Provider
+	config   *ResilientConfig
+
+	mtx      sync.Mutex
+	breakers map[string]*breaker
+}
+
+func NewResilientProvider(provider Provider, config *ResilientConfig) *ResilientProvider {
+	if provider == nil {
+		provider = newProvider(nil)
+	}
+	if config == nil {
+		config = DefaultResilientConfig()
+	}
+	return &ResilientProvider{provider: provider, config: config, breakers: make(map[string]*breaker)}
+}
+
+func (p *ResilientProvider) GetRawData(ctx context.Context, baseURL, address string) (*RawData, error) {
+	endpoints := p.endpoints(baseURL)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		data *RawData
+		err  error
+	}
+	results := make(chan result, len(endpoints))
+	errs := make([]error, 0, len(endpoints))
+
+	next, pending := 0, 0
+	launch := func() {
+		for next < len(endpoints) {
+			endpoint := endpoints[next]
+			next++
+
+			b := p.breaker(endpoint)
+			if !b.allow() {
+				errs = append(errs, fmt.Errorf("%s: %w", endpoint, ErrCircuitOpen))
+				continue
+			}
+
+			pending++
+			go func() {
+				data, err := p.fetch(ctx, b, endpoint, address)
+				results <- result{data: data, err: err}
+			}()
+			return
+		}
+	}
+
+	launch()
+	for pending > 0 {
+		var hedge <-chan time.Time
+		var timer *time.Timer
+		if p.config.HedgeDelay > 0 && next < len(endpoints) {
+			timer = time.NewTimer(p.config.HedgeDelay)
+			hedge = timer.C
+		}
+
+		select {
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				return res.data, nil
+			}
+			errs = append(errs, res.err)
+			launch()
+		case <-hedge:
+			launch()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+
+	return nil, fmt.Errorf("all endpoints failed: %w", errors.Join(errs...))
+}
+
+// CircuitOpen reports whether requests to the endpoint are currently being skipped.
+func (p *ResilientProvider) CircuitOpen(endpoint string) bool {
+	b := p.breaker(endpoint)
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	return b.state == breakerOpen && time.Since(b.openedAt) < b.cooldown
+}
+
+func (p *ResilientProvider) endpoints(baseURL string) []string {
+	endpoints := make([]string, 0, len(p.config.Endpoints)+1)
+	seen := make(map[string]struct{}, len(p.config.Endpoints)+1)
+	for _, endpoint := range append([]string{baseURL}, p.config.Endpoints...) {
+		if endpoint == "" {
+			continue
+		}
+		if _, ok := seen[endpoint]; ok {
+			continue
+		}
+		seen[endpoint] = struct{}{}
+		endpoints = append(endpoints, endpoint)
+	}
+	if len(endpoints) == 0 {
+		endpoints = append(endpoints, Endpoint)
+	}
+	return endpoints
+}
+
+func (p *ResilientProvider) breaker(endpoint string) *breaker {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	b, ok := p.breakers[endpoint]
+	if !ok {
+		b = &breaker{threshold: p.config.BreakerThreshold, cooldown: p.config.BreakerCooldown}
+		p.breakers[endpoint] = b
+	}
+	return b
+}
+
+func (p *ResilientProvider) fetch(ctx context.Context, b *breaker, endpoint, address string) (*RawData, error) {
+	var err error
+	for attempt := 0; attempt <= p.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if sleepErr := sleep(ctx, p.backoff(attempt)); sleepErr != nil {
+				break
+			}
+		}
+
+		var data *RawData
+		data, err = p.attempt(ctx, endpoint, address)
+		if err == nil {
+			b.success()
+			return data, nil
+		}
+		if ctx.Err() != nil || !isTemporary(err) {
+			break
+		}
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		b.release()
+		return nil, fmt.Errorf("%s: %w", endpoint, ctx.Err())
+	case isTemporary(err):
+		b.failure()
+	default:
+		// the endpoint has responded, the failure is about the data itself
+		b.success()
+	}
+	return nil, fmt.Errorf("%s: %w", endpoint, err)
+}
+
+func (p *ResilientProvider) attempt(ctx context.Context, endpoint, address string) (*RawData, error) {
+	if p.config.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.config.AttemptTimeout)
+		defer cancel()
+	}
+	return p.provider.GetRawData(ctx, endpoint, address)
+}
+
+func (p *ResilientProvider) backoff(attempt int) time.Duration {
+	d := p.config.BackoffBase << (attempt - 1)
+	if p.config.BackoffMax > 0 && (d > p.config.BackoffMax || d <= 0) {
+		d = p.config.BackoffMax
+	}
+	if d <= 0 {
+		return 0
+	}
+	// equal jitter keeps the delay within [d/2, d)
+	return d/2 + rand.N(d/2+1)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func isTemporary(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Temporary()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mtx      sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+func (b *breaker) allow() bool {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		return true
+	case breakerHalfOpen:
+		// a single probe request is already in flight
+		return false
+	default:
+		return true
+	}
+}
+
+func (b *breaker) success() {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.state = breakerClosed
+	b.failures = 0
+}
+
+func (b *breaker) failure() {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.failures++
+	if b.state == breakerHalfOpen || (b.threshold > 0 && b.failures >= b.threshold) {
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// release gives up a half-open probe which was cancelled before it could tell anything.
+func (b *breaker) release() {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if b.state == breakerHalfOpen {
+		b.state = breakerOpen
+	}
+}
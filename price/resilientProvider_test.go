@@ -0,0 +1,164 @@
+package price
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testOracleAddress = "0xd3a8c0b9fd44fd25a49289c631e3ac45689281f2f8cf0744400b4c65bed38e5d"
+
+// newIndexerServer serves testFeature the way the IOTA indexer and core APIs do,
+// fail decides per request which status code to respond with instead (0 means success).
+func newIndexerServer(t *testing.T, fail func(r *http.Request) int) (*httptest.Server, *atomic.Int32) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if code := fail(r); code != 0 {
+			w.WriteHeader(code)
+			return
+		}
+		switch {
+		case strings.HasPrefix(r.URL.Path, outputsEndpoint):
+			_, _ = w.Write([]byte(`{"items":["0x01"]}`))
+		case strings.HasPrefix(r.URL.Path, coreEndpoint):
+			_, _ = w.Write([]byte(`{"output":{"features":[{"data":"` + testFeature + `"}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestProvider_GetRawData_status(t *testing.T) {
+	server, _ := newIndexerServer(t, func(*http.Request) int { return http.StatusServiceUnavailable })
+
+	_, err := newProvider(nil).GetRawData(context.Background(), server.URL, testOracleAddress)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("error want *StatusError, got %v", err)
+	}
+	if statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("StatusCode want %d, got %d", http.StatusServiceUnavailable, statusErr.StatusCode)
+	}
+}
+
+func TestResilientProvider_GetRawData_retry(t *testing.T) {
+	var calls atomic.Int32
+	server, requests := newIndexerServer(t, func(*http.Request) int {
+		if calls.Add(1) <= 2 {
+			return http.StatusBadGateway
+		}
+		return 0
+	})
+
+	provider := NewResilientProvider(nil, &ResilientConfig{MaxRetries: 2, BackoffBase: time.Millisecond})
+	rawData, err := provider.GetRawData(context.Background(), server.URL, testOracleAddress)
+	if err != nil {
+		t.Fatalf("failed to GetRawData, err: %s", err)
+	}
+	if rawData.Timestamp != 1730559229 {
+		t.Errorf("Timestamp want %d, got %d", 1730559229, rawData.Timestamp)
+	}
+	if requests.Load() != 4 {
+		t.Errorf("requests want %d, got %d", 4, requests.Load())
+	}
+}
+
+func TestResilientProvider_GetRawData_permanentError(t *testing.T) {
+	server, requests := newIndexerServer(t, func(*http.Request) int { return http.StatusNotFound })
+
+	provider := NewResilientProvider(nil, &ResilientConfig{MaxRetries: 3, BackoffBase: time.Millisecond, BreakerThreshold: 1})
+	if _, err := provider.GetRawData(context.Background(), server.URL, testOracleAddress); err == nil {
+		t.Fatalf("GetRawData want error, got nil")
+	}
+	if requests.Load() != 1 {
+		t.Errorf("requests want %d, got %d", 1, requests.Load())
+	}
+	if provider.CircuitOpen(server.URL) {
+		t.Errorf("CircuitOpen want false, got true")
+	}
+}
+
+func TestResilientProvider_GetRawData_circuitBreaker(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	broken, brokenRequests := newIndexerServer(t, func(*http.Request) int {
+		if down.Load() {
+			return http.StatusInternalServerError
+		}
+		return 0
+	})
+	healthy, _ := newIndexerServer(t, func(*http.Request) int { return 0 })
+
+	provider := NewResilientProvider(nil, &ResilientConfig{
+		Endpoints:        []string{healthy.URL},
+		BreakerThreshold: 2,
+		BreakerCooldown:  100 * time.Millisecond,
+	})
+	for i := 0; i < 4; i++ {
+		if _, err := provider.GetRawData(context.Background(), broken.URL, testOracleAddress); err != nil {
+			t.Fatalf("failed to GetRawData, err: %s", err)
+		}
+	}
+	if brokenRequests.Load() != 2 {
+		t.Errorf("requests to broken endpoint want %d, got %d", 2, brokenRequests.Load())
+	}
+	if !provider.CircuitOpen(broken.URL) {
+		t.Errorf("CircuitOpen want true, got false")
+	}
+
+	down.Store(false)
+	time.Sleep(150 * time.Millisecond)
+	if _, err := provider.GetRawData(context.Background(), broken.URL, testOracleAddress); err != nil {
+		t.Fatalf("failed to GetRawData, err: %s", err)
+	}
+	if provider.CircuitOpen(broken.URL) {
+		t.Errorf("CircuitOpen after recovery want false, got true")
+	}
+}
+
+func TestResilientProvider_GetRawData_hedge(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+	fast, _ := newIndexerServer(t, func(*http.Request) int { return 0 })
+
+	provider := NewResilientProvider(nil, &ResilientConfig{
+		Endpoints:  []string{fast.URL},
+		HedgeDelay: 20 * time.Millisecond,
+	})
+	start := time.Now()
+	if _, err := provider.GetRawData(context.Background(), slow.URL, testOracleAddress); err != nil {
+		t.Fatalf("failed to GetRawData, err: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("hedged request took %s", elapsed)
+	}
+}
+
+func TestResilientProvider_GetRawData_allFailed(t *testing.T) {
+	first, _ := newIndexerServer(t, func(*http.Request) int { return http.StatusInternalServerError })
+	second, _ := newIndexerServer(t, func(*http.Request) int { return http.StatusTooManyRequests })
+
+	provider := NewResilientProvider(nil, &ResilientConfig{Endpoints: []string{second.URL}})
+	_, err := provider.GetRawData(context.Background(), first.URL, testOracleAddress)
+	if err == nil {
+		t.Fatalf("GetRawData want error, got nil")
+	}
+	if !strings.Contains(err.Error(), first.URL) || !strings.Contains(err.Error(), second.URL) {
+		t.Errorf("error should mention both endpoints, got %s", err)
+	}
+}
@@ -2,12 +2,15 @@ package price
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
+	"slices"
 	"sort"
+	"time"
 
 	"github.com/xssnick/tonutils-go/tvm/cell"
-	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
 
 	"github.com/evaafi/evaa-go-sdk/config"
 )
@@ -20,13 +23,19 @@ type Prices struct {
 	list         map[string]*big.Int
 	data         *cell.Cell
 	minTimestamp int64
+	oracles      []*Data
+	synthetic    bool
 }
 
 func (p *Prices) Get(asset string) *big.Int {
 	return p.list[asset]
 }
 
+// Data returns the packed prices with oracle proofs for on-chain operations, it is nil for synthetic prices.
 func (p *Prices) Data() *cell.Cell {
+	if p.synthetic {
+		return nil
+	}
 	return p.data
 }
 
@@ -34,9 +43,15 @@ func (p *Prices) MinTimestamp() int64 {
 	return p.minTimestamp
 }
 
+// DefaultConcurrency is the default limit of oracle requests a single GetPrices call keeps in flight.
+const DefaultConcurrency = 16
+
 type Service struct {
 	config        *config.Config
 	provider      Provider
+	policy        *Policy
+	detector      *Detector
+	concurrency   int
 	proofSkeleton *cell.ProofSkeleton
 }
 
@@ -46,7 +61,36 @@ func NewService(config *config.Config, provider Provider) *Service {
 	}
 	proofSkeleton := cell.CreateProofSkeleton()
 	proofSkeleton.SetRecursive()
-	return &Service{config: config, provider: provider, proofSkeleton: proofSkeleton}
+	return &Service{config: config, provider: provider, policy: DefaultPolicy(config), concurrency: DefaultConcurrency, proofSkeleton: proofSkeleton}
+}
+
+// SetPolicy replaces the oracle acceptance policy, nil restores DefaultPolicy.
+// Zero MaxAge and Quorum of the policy fall back to the default ones.
+// It must not be called concurrently with GetPrices.
+func (s *Service) SetPolicy(policy *Policy) *Service {
+	if policy == nil {
+		policy = DefaultPolicy(s.config)
+	}
+	s.policy = policy.withDefaults(s.config)
+	return s
+}
+
+// SetDetector makes every GetPrices call inspect the oracle data with the detector before returning.
+// It must not be called concurrently with GetPrices.
+func (s *Service) SetDetector(detector *Detector) *Service {
+	s.detector = detector
+	return s
+}
+
+// SetConcurrency limits the number of oracle requests across all oracles and endpoints
+// a single GetPrices call keeps in flight, values below 1 restore DefaultConcurrency.
+// It must not be called concurrently with GetPrices.
+func (s *Service) SetConcurrency(concurrency int) *Service {
+	if concurrency < 1 {
+		concurrency = DefaultConcurrency
+	}
+	s.concurrency = concurrency
+	return s
 }
 
 type Data struct {
@@ -55,105 +99,131 @@ type Data struct {
 }
 
 func (s *Service) GetPrices(ctx context.Context, endpoint ...string) (*Prices, error) {
+	prices, _, err := s.GetPricesDetailed(ctx, endpoint...)
+	return prices, err
+}
+
+// GetPricesDetailed works like GetPrices and additionally reports how every oracle was fetched
+// and why it was accepted or rejected. The report is returned even if the prices are not.
+func (s *Service) GetPricesDetailed(ctx context.Context, endpoint ...string) (*Prices, *Report, error) {
+	prices, report, err := s.getPricesDetailed(ctx, endpoint...)
+	if s.detector != nil {
+		report.Alerts = s.detector.Inspect(prices, report)
+	}
+	return prices, report, err
+}
+
+func (s *Service) getPricesDetailed(ctx context.Context, endpoint ...string) (*Prices, *Report, error) {
 	if len(endpoint) == 0 {
 		endpoint = append(endpoint, Endpoint)
 	}
-	ch := make(chan *Data)
-	var wg errgroup.Group
+	sem := semaphore.NewWeighted(int64(s.concurrency))
+	ch := make(chan *OracleReport, len(s.config.Oracles))
 	for _, oracle := range s.config.Oracles {
-		oracle := oracle
-		wg.Go(func() error {
-			if len(endpoint) == 1 {
-				data, err := s.provider.GetRawData(ctx, endpoint[0], oracle.Address)
-				if err != nil {
-					return fmt.Errorf("failed to get oracle %d data form %s, err: %w", oracle.ID, endpoint[0], err)
-				}
-				ch <- &Data{
-					RawData:  data,
-					oracleID: oracle.ID,
-				}
-				return nil
-			}
-
-			var wg1 errgroup.Group
-			oracleCh := make(chan *RawData)
-			for _, baseURL := range endpoint {
-				baseURL := baseURL
-				wg1.Go(func() error {
-					data, err := s.provider.GetRawData(ctx, baseURL, oracle.Address)
-					if err != nil {
-						return fmt.Errorf("failed to get oracle %d data from %s, err: %w", oracle.ID, baseURL, err)
-					}
-					oracleCh <- data
-					return nil
-				})
-			}
+		go func() {
+			ch <- s.fetchOracle(ctx, sem, oracle, endpoint)
+		}()
+	}
 
-			var endpointErr error
-			go func() {
-				endpointErr = wg1.Wait()
-				close(oracleCh)
-			}()
+	report := &Report{Oracles: make(map[uint64]*OracleReport, len(s.config.Oracles))}
+	now := time.Now()
+	validPrices := make([]*Data, 0, len(s.config.Oracles))
+	for range s.config.Oracles {
+		oracleReport := <-ch
+		report.Oracles[oracleReport.OracleID] = oracleReport
+		if oracleReport.Reason != nil {
+			continue
+		}
+		if err := s.policy.check(oracleReport.Data, s.config.Assets, now); err != nil {
+			oracleReport.reject(err)
+			continue
+		}
+		validPrices = append(validPrices, &Data{RawData: oracleReport.Data, oracleID: oracleReport.OracleID})
+	}
+	report.Spread = calculateSpread(validPrices, s.config.Assets)
 
-			data, ok := <-oracleCh
-			if !ok {
-				return endpointErr
-			}
-			ch <- &Data{
-				RawData:  data,
-				oracleID: oracle.ID,
-			}
-			return nil
-		})
+	acceptedPrices, rejected, err := s.policy.selectData(validPrices, s.config.Assets)
+	for oracleID, reason := range rejected {
+		report.Oracles[oracleID].reject(reason)
+	}
+	if err != nil {
+		if reasons := report.err(); reasons != nil {
+			return nil, report, fmt.Errorf("prices is outdated, %w, err: %w", err, reasons)
+		}
+		return nil, report, fmt.Errorf("prices is outdated, %w", err)
 	}
 
-	var oracleErr error
-	go func() {
-		oracleErr = wg.Wait()
-		close(ch)
-	}()
+	for _, data := range acceptedPrices {
+		report.Oracles[data.oracleID].Accepted = true
+	}
 
-	acceptedPrices := make([]*Data, 0, len(s.config.Oracles))
-	for data := range ch {
-		if !data.verify(s.config.Assets) {
-			continue
-		}
-		acceptedPrices = append(acceptedPrices, data)
+	prices, err := packPrices(s.config.Assets, acceptedPrices, s.proofSkeleton)
+	if err != nil {
+		return nil, report, err
 	}
+	return prices, report, nil
+}
+
+// fetchOracle queries the oracle data from all endpoints at once and reports the first successful response,
+// requests to the other endpoints are cancelled. Every request holds sem while in flight.
+func (s *Service) fetchOracle(ctx context.Context, sem *semaphore.Weighted, oracle *config.OracleNFT, endpoint []string) *OracleReport {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-	if len(acceptedPrices) < s.config.MinimalOracles {
-		return nil, fmt.Errorf("prices is outdated, err: %w", oracleErr)
+	type result struct {
+		data     *RawData
+		endpoint string
+		latency  time.Duration
+		err      error
 	}
 
-	sort.Slice(acceptedPrices, func(i, j int) bool {
-		return acceptedPrices[i].Timestamp > acceptedPrices[j].Timestamp
-	})
+	start := time.Now()
+	// buffered, so the losing requests never block on send once the winner is taken
+	results := make(chan *result, len(endpoint))
+	for _, baseURL := range endpoint {
+		go func() {
+			if err := sem.Acquire(ctx, 1); err != nil {
+				results <- &result{endpoint: baseURL, err: fmt.Errorf("failed to get oracle %d data from %s, err: %w", oracle.ID, baseURL, err)}
+				return
+			}
+			defer sem.Release(1)
 
-	if len(acceptedPrices) != s.config.MinimalOracles {
-		acceptedPrices = acceptedPrices[:s.config.MinimalOracles]
+			data, err := s.provider.GetRawData(ctx, baseURL, oracle.Address)
+			if err != nil {
+				err = fmt.Errorf("failed to get oracle %d data from %s, err: %w", oracle.ID, baseURL, err)
+			}
+			results <- &result{data: data, endpoint: baseURL, latency: time.Since(start), err: err}
+		}()
 	}
 
-	minTimestamp := acceptedPrices[0].Timestamp
-	isOddMinOraclesCount := s.config.MinimalOracles%2 == 1
-	medianIndex := s.config.MinimalOracles / 2
-
-	medianPrices := make(map[string]*big.Int, len(s.config.Assets))
-	for k, _ := range s.config.Assets {
-		sort.SliceStable(acceptedPrices, func(i, j int) bool {
-			return acceptedPrices[i].Prices()[k].Cmp(acceptedPrices[j].Prices()[k]) != 1
-		})
-		if isOddMinOraclesCount {
-			medianPrices[k] = acceptedPrices[medianIndex].Prices()[k]
-		} else {
-			medianPrices[k] = new(big.Int).Div(new(big.Int).Add(acceptedPrices[medianIndex-1].Prices()[k], acceptedPrices[medianIndex].Prices()[k]), big.NewInt(2))
+	errs := make([]error, 0, len(endpoint))
+	for range endpoint {
+		res := <-results
+		if res.err == nil {
+			return newOracleReport(oracle.ID, res.endpoint, res.latency, res.data, nil)
 		}
+		errs = append(errs, res.err)
+	}
+	if len(endpoint) == 1 {
+		return newOracleReport(oracle.ID, endpoint[0], time.Since(start), nil, errs[0])
+	}
+	return newOracleReport(oracle.ID, "", time.Since(start), nil, errors.Join(errs...))
+}
+
+// packPrices calculates the median prices of the accepted oracle data and packs them with the oracle proofs.
+func packPrices(assets map[string]*config.AssetConfig, acceptedPrices []*Data, proofSkeleton *cell.ProofSkeleton) (*Prices, error) {
+	acceptedPrices = slices.Clone(acceptedPrices)
+	minTimestamp := acceptedPrices[0].Timestamp
+	for _, data := range acceptedPrices {
+		minTimestamp = min(minTimestamp, data.Timestamp)
 	}
+	medianPrices := calculateMedians(acceptedPrices, assets)
 
 	var packedMedianData *cell.Cell
-	for asset, median := range medianPrices {
+	for _, asset := range sortedKeys(medianPrices) {
 		packedMedianData = cell.BeginCell().
-			MustStoreBigUInt(s.config.Assets[asset].ID, 256).
-			MustStoreBigCoins(median).
+			MustStoreBigUInt(assets[asset].ID, 256).
+			MustStoreBigCoins(medianPrices[asset]).
 			MustStoreMaybeRef(packedMedianData).
 			EndCell()
 	}
@@ -167,7 +237,7 @@ func (s *Service) GetPrices(ctx context.Context, endpoint ...string) (*Prices, e
 		prf, err := cell.BeginCell().
 			MustStoreUInt(uint64(price.Timestamp), 32).
 			MustStoreMaybeRef(price.PricesDict.AsCell()).
-			EndCell().CreateProof(s.proofSkeleton)
+			EndCell().CreateProof(proofSkeleton)
 		if err != nil {
 			return nil, fmt.Errorf("createProof err: %s", err)
 		}
@@ -184,5 +254,6 @@ func (s *Service) GetPrices(ctx context.Context, endpoint ...string) (*Prices, e
 		list:         medianPrices,
 		data:         cell.BeginCell().MustStoreRef(packedMedianData).MustStoreRef(packedOracleData).EndCell(),
 		minTimestamp: minTimestamp,
+		oracles:      acceptedPrices,
 	}, nil
 }
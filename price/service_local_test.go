@@ -0,0 +1,100 @@
+package price_test
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+	"github.com/evaafi/evaa-go-sdk/price"
+	"github.com/evaafi/evaa-go-sdk/price/pricetest"
+)
+
+func newLocalOracles(t *testing.T) (*pricetest.Server, *config.Config) {
+	oracles, err := pricetest.NewOracles(4)
+	if err != nil {
+		t.Fatalf("failed to create oracles, err: %s", err)
+	}
+	server := pricetest.NewServer(oracles...)
+	t.Cleanup(server.Close)
+
+	cfg := pricetest.Config(oracles...)
+	if err := server.SetPrices(pricetest.Prices(cfg, big.NewInt(4_575_000_000)), time.Now().Unix()); err != nil {
+		t.Fatalf("failed to set prices, err: %s", err)
+	}
+	return server, cfg
+}
+
+func TestService_GetPrices_local(t *testing.T) {
+	server, cfg := newLocalOracles(t)
+	server.SetFailure(cfg.Oracles[0].Address, http.StatusInternalServerError)
+
+	service := price.NewService(cfg, nil)
+	prices, err := service.GetPrices(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("failed to get prices, err: %s", err)
+	}
+	for k := range cfg.Assets {
+		if got := prices.Get(k); got == nil || got.Cmp(big.NewInt(4_575_000_000)) != 0 {
+			t.Errorf("price of %s want %d, got %v", cfg.Assets[k].Name, 4_575_000_000, got)
+		}
+	}
+	if prices.MinTimestamp() == 0 {
+		t.Errorf("minTimestamp is zero")
+	}
+	if prices.Data() == nil {
+		t.Errorf("data is empty")
+	}
+
+	server.SetFailure(cfg.Oracles[1].Address, http.StatusNotFound)
+	if _, err := service.GetPrices(context.Background(), server.URL); err == nil {
+		t.Errorf("GetPrices without quorum want error, got nil")
+	}
+}
+
+func TestService_GetPrices_localSlowEndpoint(t *testing.T) {
+	oracles, err := pricetest.NewOracles(4)
+	if err != nil {
+		t.Fatalf("failed to create oracles, err: %s", err)
+	}
+	cfg := pricetest.Config(oracles...)
+	slow, fast := pricetest.NewServer(oracles...), pricetest.NewServer(oracles...)
+	t.Cleanup(slow.Close)
+	t.Cleanup(fast.Close)
+	for _, server := range []*pricetest.Server{slow, fast} {
+		if err := server.SetPrices(pricetest.Prices(cfg, big.NewInt(4_575_000_000)), time.Now().Unix()); err != nil {
+			t.Fatalf("failed to set prices, err: %s", err)
+		}
+	}
+	slow.SetDelay(time.Minute)
+
+	start := time.Now()
+	_, report, err := price.NewService(cfg, nil).GetPricesDetailed(context.Background(), slow.URL, fast.URL)
+	if err != nil {
+		t.Fatalf("failed to get prices, err: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("GetPrices waited for the slow endpoint, took %s", elapsed)
+	}
+	for id, oracle := range report.Oracles {
+		if oracle.Endpoint != fast.URL {
+			t.Errorf("oracle %d Endpoint want %s, got %s", id, fast.URL, oracle.Endpoint)
+		}
+	}
+}
+
+func TestService_GetPrices_localQuorumNotMet(t *testing.T) {
+	server, cfg := newLocalOracles(t)
+
+	_, _, err := price.NewService(cfg, nil).SetPolicy(&price.Policy{MaxAge: time.Minute, Quorum: 5}).
+		GetPricesDetailed(context.Background(), server.URL)
+	if err == nil {
+		t.Fatalf("GetPricesDetailed without quorum want error, got nil")
+	}
+	if strings.Contains(err.Error(), "%!") {
+		t.Errorf("error is misformatted: %s", err)
+	}
+}
@@ -2,74 +2,100 @@ package price
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/evaafi/evaa-go-sdk/config"
 )
 
-func TestService_GetPrices(t *testing.T) {
+func TestService_GetPrices_fanOut(t *testing.T) {
 	cfg := config.GetMainMainnetConfig()
-	service := NewService(cfg, newProvider(nil))
-	prices, err := service.GetPrices(context.Background(), Endpoint, "https://evaa.space")
-	if err != nil {
-		t.Fatalf("failed to get prices, err: %s", err)
-	}
-	if len(prices.list) < len(cfg.Assets) {
-		t.Errorf("prices count want >%d, got %d", len(cfg.Assets), len(prices.list))
-	}
-	for k, v := range prices.list {
-		t.Logf("%5s: %10s", cfg.Assets[k].Name, v.String())
-	}
-	if prices.minTimestamp == 0 {
-		t.Errorf("minTimestamp is zero")
+	var inFlight atomic.Int32
+	provider := stubProvider(func(ctx context.Context, baseURL, _ string) (*RawData, error) {
+		inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		switch baseURL {
+		case "slow":
+			<-ctx.Done()
+			return nil, ctx.Err()
+		case "broken":
+			return nil, errors.New("endpoint is down")
+		default:
+			time.Sleep(10 * time.Millisecond)
+			return newTestRawData(cfg.Assets, time.Now().Unix(), 100), nil
+		}
+	})
+
+	service := NewService(cfg, provider)
+	for i := 0; i < 10; i++ {
+		_, report, err := service.GetPricesDetailed(context.Background(), "slow", "broken", "fast")
+		if err != nil {
+			t.Fatalf("failed to get prices, err: %s", err)
+		}
+		for id, oracle := range report.Oracles {
+			if oracle.Endpoint != "fast" {
+				t.Errorf("oracle %d Endpoint want %s, got %s", id, "fast", oracle.Endpoint)
+			}
+		}
 	}
-	if prices.data == nil {
-		t.Errorf("data is empty")
+
+	// losing requests are cancelled rather than left behind
+	deadline := time.Now().Add(time.Second)
+	for inFlight.Load() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("requests in flight want %d, got %d", 0, inFlight.Load())
+		}
+		time.Sleep(time.Millisecond)
 	}
 }
 
-func TestService_GetPrices_multiEndpoint(t *testing.T) {
+func TestService_GetPrices_allEndpointsFailed(t *testing.T) {
 	cfg := config.GetMainMainnetConfig()
-	service := NewService(cfg, newProvider(nil))
-	prices, err := service.GetPrices(context.Background(), "https://evaa.space", "http://localhost", Endpoint)
-	if err != nil {
-		t.Fatalf("failed to get prices, err: %s", err)
-	}
-	if len(prices.list) < len(cfg.Assets) {
-		t.Errorf("prices count want >%d, got %d", len(cfg.Assets), len(prices.list))
-	}
-	for k, v := range prices.list {
-		t.Logf("%5s: %10s", cfg.Assets[k].Name, v.String())
-	}
-	if prices.minTimestamp == 0 {
-		t.Errorf("minTimestamp is zero")
+	provider := stubProvider(func(_ context.Context, baseURL, _ string) (*RawData, error) {
+		return nil, errors.New(baseURL + " is down")
+	})
+
+	_, report, err := NewService(cfg, provider).GetPricesDetailed(context.Background(), "first", "second")
+	if !errors.Is(err, ErrOracleUnreachable) {
+		t.Fatalf("error want %s, got %v", ErrOracleUnreachable, err)
 	}
-	if prices.data == nil {
-		t.Errorf("data is empty")
+	for id, oracle := range report.Oracles {
+		if reason := oracle.Reason.Error(); !strings.Contains(reason, "first is down") || !strings.Contains(reason, "second is down") {
+			t.Errorf("oracle %d Reason should mention both endpoints, got %s", id, reason)
+		}
 	}
 }
 
-func TestService_GetPrices_singleEndpoint(t *testing.T) {
+func TestService_GetPrices_concurrency(t *testing.T) {
 	cfg := config.GetMainMainnetConfig()
-	endpointProvider := newSingleEndpointProvider(nil)
-	go endpointProvider.Update(context.Background(), "https://evaa.space/api/prices", time.Second)
-	service := NewService(cfg, endpointProvider)
-	time.Sleep(3 * time.Second)
-	prices, err := service.GetPrices(context.Background())
-	if err != nil {
+	var inFlight, maxInFlight atomic.Int32
+	provider := stubProvider(func(ctx context.Context, _, _ string) (*RawData, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			current := maxInFlight.Load()
+			if n <= current || maxInFlight.CompareAndSwap(current, n) {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(5 * time.Millisecond):
+			return newTestRawData(cfg.Assets, time.Now().Unix(), 100), nil
+		}
+	})
+
+	service := NewService(cfg, provider).SetConcurrency(2)
+	if _, err := service.GetPrices(context.Background(), "first", "second", "third"); err != nil {
 		t.Fatalf("failed to get prices, err: %s", err)
 	}
-	if len(prices.list) < len(cfg.Assets) {
-		t.Errorf("prices count want >%d, got %d", len(cfg.Assets), len(prices.list))
-	}
-	for k, v := range prices.list {
-		t.Logf("%5s: %10s", cfg.Assets[k].Name, v.String())
-	}
-	if prices.minTimestamp == 0 {
-		t.Errorf("minTimestamp is zero")
-	}
-	if prices.data == nil {
-		t.Errorf("data is empty")
+	if maxInFlight.Load() > 2 {
+		t.Errorf("requests in flight want at most %d, got %d", 2, maxInFlight.Load())
 	}
 }
@@ -18,7 +18,7 @@ type SingleEndpointProvider struct {
 	mtx    sync.RWMutex
 }
 
-func newSingleEndpointProvider(client *http.Client) *SingleEndpointProvider {
+func NewSingleEndpointProvider(client *http.Client) *SingleEndpointProvider {
 	if client == nil {
 		client = http.DefaultClient
 	}
@@ -36,6 +36,9 @@ func (p *SingleEndpointProvider) update(ctx context.Context, url string) (map[st
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer outputsResp.Body.Close()
+	if err := checkStatus(outputsResp); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
 
 	var list = map[string]string{}
 	if err := json.NewDecoder(outputsResp.Body).Decode(&list); err != nil {
@@ -2,34 +2,73 @@ package price
 
 import (
 	"context"
+	"math/big"
 	"testing"
 	"time"
 
 	"github.com/evaafi/evaa-go-sdk/config"
+	"github.com/evaafi/evaa-go-sdk/price/pricetest"
 )
 
-func TestSingleEndpointProvider_GetRawData(t *testing.T) {
-	service := newSingleEndpointProvider(nil)
+// waitForRawData polls the provider until it serves the oracle data or the timeout is reached.
+func waitForRawData(t *testing.T, provider Provider, address string, timeout time.Duration) *RawData {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for {
+		rawData, err := provider.GetRawData(context.Background(), "", address)
+		if err == nil {
+			return rawData
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("oracle %s data is not available after %s, err: %s", address, timeout, err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func newSingleEndpointOracles(t *testing.T) (*SingleEndpointProvider, *config.Config) {
+	oracles, err := pricetest.NewOracles(4)
+	if err != nil {
+		t.Fatalf("failed to create oracles, err: %s", err)
+	}
+	server := pricetest.NewServer(oracles...)
+	t.Cleanup(server.Close)
+
+	cfg := pricetest.Config(oracles...)
+	if err := server.SetPrices(pricetest.Prices(cfg, big.NewInt(4_575_000_000)), time.Now().Unix()); err != nil {
+		t.Fatalf("failed to set prices, err: %s", err)
+	}
+
+	provider := NewSingleEndpointProvider(nil)
 	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go provider.Update(ctx, server.URL+pricetest.PricesPath, 10*time.Millisecond)
+	return provider, cfg
+}
 
-	go func() {
-		<-time.After(3 * time.Second)
-		cancel()
-	}()
+func TestSingleEndpointProvider_GetRawData(t *testing.T) {
+	provider, cfg := newSingleEndpointOracles(t)
 
-	err := service.Update(ctx, "https://evaa.space/api/prices", time.Second)
-	if err != nil {
-		t.Fatalf("failed to GetRawData, err: %s", err)
+	rawData := waitForRawData(t, provider, cfg.Oracles[0].Address, time.Second)
+	if err := DefaultPolicy(cfg).check(rawData, cfg.Assets, time.Now()); err != nil {
+		t.Errorf("check want nil, got %s", err)
+	}
+	if _, err := provider.GetRawData(context.Background(), "", "0xunknown"); err == nil {
+		t.Errorf("GetRawData of an unknown oracle want error, got nil")
 	}
+}
 
-	rawData, err := service.GetRawData(context.Background(), "", "0xd3a8c0b9fd44fd25a49289c631e3ac45689281f2f8cf0744400b4c65bed38e5d")
-	if err != nil {
-		t.Fatalf("failed to GetRawData, err: %s", err)
+func TestService_GetPrices_singleEndpointLocal(t *testing.T) {
+	provider, cfg := newSingleEndpointOracles(t)
+	for _, oracle := range cfg.Oracles {
+		waitForRawData(t, provider, oracle.Address, time.Second)
 	}
-	if !rawData.verify(config.GetMainMainnetConfig().Assets) {
-		t.Errorf("verify want true, got false")
+
+	prices, err := NewService(cfg, provider).GetPrices(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get prices, err: %s", err)
 	}
-	for k, v := range rawData.Prices() {
-		t.Logf("%s: %10s", k, v.String())
+	if got := prices.Get(config.TON.ID()); got == nil || got.Cmp(big.NewInt(4_575_000_000)) != 0 {
+		t.Errorf("TON price want %d, got %v", 4_575_000_000, got)
 	}
 }
@@ -0,0 +1,51 @@
+package price
+
+import (
+	"context"
+	"math/big"
+	"time"
+)
+
+// Update is a single Subscribe event, either fresh prices or the error of the latest fetch.
+type Update struct {
+	Prices *Prices
+	Err    error
+}
+
+// Subscribe fetches prices every interval until ctx is done and sends an update when a median price
+// of any asset changes, when the previously sent prices are about to expire and newer ones are available,
+// or when fetching fails. The first successful fetch, including the first one after an error, is always sent.
+// A non-positive interval falls back to DefaultRefreshInterval.
+//
+// It runs a CachedService of its own, use CachedService.Subscribe to share a single one between subscribers.
+// A slow consumer only gets the most recent update, older undelivered ones are dropped.
+// The channel is closed once ctx is done.
+func (s *Service) Subscribe(ctx context.Context, interval time.Duration, endpoint ...string) <-chan *Update {
+	cached := NewCachedService(s, interval, endpoint...)
+	ch := cached.Subscribe(ctx)
+	go cached.Run(ctx)
+	return ch
+}
+
+// changed reports whether prices are worth sending to a subscriber who has last seen the previous ones.
+func (s *Service) changed(previous, prices *Prices, interval time.Duration) bool {
+	if previous == nil || !equalPrices(previous.list, prices.list) {
+		return true
+	}
+	// the previous prices will be expired by the next fetch, so newer proofs are needed to keep using them
+	expiresAt := time.Unix(previous.MinTimestamp(), 0).Add(s.policy.MaxAge)
+	return time.Until(expiresAt) <= interval && prices.MinTimestamp() > previous.MinTimestamp()
+}
+
+func equalPrices(a, b map[string]*big.Int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for asset, price := range a {
+		other, ok := b[asset]
+		if !ok || price.Cmp(other) != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,150 @@
+package price
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+func receive(t *testing.T, ch <-chan *Update) *Update {
+	t.Helper()
+	select {
+	case update, ok := <-ch:
+		if !ok {
+			t.Fatalf("channel is closed")
+		}
+		return update
+	case <-time.After(time.Second):
+		t.Fatalf("no update received")
+		return nil
+	}
+}
+
+func TestService_Subscribe(t *testing.T) {
+	cfg := config.GetMainMainnetConfig()
+	tonAsset := config.TON.ID()
+	var price atomic.Int64
+	price.Store(100)
+	var fail atomic.Bool
+	provider := stubProvider(func(context.Context, string, string) (*RawData, error) {
+		if fail.Load() {
+			return nil, errors.New("oracle is down")
+		}
+		return newTestRawData(cfg.Assets, time.Now().Unix(), price.Load()), nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := NewService(cfg, provider).Subscribe(ctx, 10*time.Millisecond)
+
+	if update := receive(t, ch); update.Err != nil || update.Prices.Get(tonAsset).Cmp(big.NewInt(100)) != 0 {
+		t.Fatalf("first update want price %d, got %+v", 100, update)
+	}
+
+	select {
+	case update := <-ch:
+		t.Fatalf("unchanged prices should not be sent, got %+v", update)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	price.Store(200)
+	if update := receive(t, ch); update.Err != nil || update.Prices.Get(tonAsset).Cmp(big.NewInt(200)) != 0 {
+		t.Fatalf("update want price %d, got %+v", 200, update)
+	}
+
+	fail.Store(true)
+	if update := receive(t, ch); update.Err == nil {
+		t.Fatalf("update want error, got %+v", update)
+	}
+	fail.Store(false)
+	for {
+		// error updates may still be pending
+		if update := receive(t, ch); update.Err == nil {
+			if update.Prices.Get(tonAsset).Cmp(big.NewInt(200)) != 0 {
+				t.Fatalf("update after recovery want price %d, got %s", 200, update.Prices.Get(tonAsset))
+			}
+			break
+		}
+	}
+
+	cancel()
+	for range ch {
+	}
+}
+
+func TestService_Subscribe_slowConsumer(t *testing.T) {
+	cfg := config.GetMainMainnetConfig()
+	var calls atomic.Int64
+	provider := stubProvider(func(context.Context, string, string) (*RawData, error) {
+		// every fetch round yields a new price
+		return newTestRawData(cfg.Assets, time.Now().Unix(), 100+calls.Add(1)/int64(len(cfg.Oracles))), nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := NewService(cfg, provider).Subscribe(ctx, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+
+	// the first prices were replaced by newer ones instead of blocking the subscription
+	if got := receive(t, ch).Prices.Get(config.TON.ID()); got.Cmp(big.NewInt(103)) < 0 {
+		t.Errorf("slow consumer want one of the latest prices, got %s", got)
+	}
+
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		for range ch {
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("channel is not closed after cancel")
+	}
+}
+
+func TestService_Subscribe_zeroInterval(t *testing.T) {
+	cfg := config.GetMainMainnetConfig()
+	provider := stubProvider(func(context.Context, string, string) (*RawData, error) {
+		return newTestRawData(cfg.Assets, time.Now().Unix(), 100), nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if update := receive(t, NewService(cfg, provider).Subscribe(ctx, 0)); update.Err != nil {
+		t.Fatalf("first update want prices, got err: %s", update.Err)
+	}
+}
+
+func TestService_changed(t *testing.T) {
+	cfg := config.GetMainMainnetConfig()
+	service := NewService(cfg, nil).SetPolicy(&Policy{MaxAge: time.Minute})
+	now := time.Now().Unix()
+	list := map[string]*big.Int{config.TON.ID(): big.NewInt(100)}
+
+	tests := []struct {
+		name     string
+		previous *Prices
+		prices   *Prices
+		want     bool
+	}{
+		{"first", nil, &Prices{list: list, minTimestamp: now}, true},
+		{"unchanged", &Prices{list: list, minTimestamp: now - 10}, &Prices{list: list, minTimestamp: now}, false},
+		{"median changed", &Prices{list: list, minTimestamp: now}, &Prices{list: map[string]*big.Int{config.TON.ID(): big.NewInt(101)}, minTimestamp: now}, true},
+		{"asset added", &Prices{list: list, minTimestamp: now}, &Prices{list: map[string]*big.Int{config.TON.ID(): big.NewInt(100), config.USDT.ID(): big.NewInt(1)}, minTimestamp: now}, true},
+		{"near expiry", &Prices{list: list, minTimestamp: now - 55}, &Prices{list: list, minTimestamp: now}, true},
+		{"near expiry without newer data", &Prices{list: list, minTimestamp: now - 55}, &Prices{list: list, minTimestamp: now - 55}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := service.changed(tt.previous, tt.prices, 10*time.Second); got != tt.want {
+				t.Errorf("changed want %t, got %t", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package price
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+)
+
+var (
+	ErrInvalidFactor = errors.New("price factor must be positive")
+	ErrInvalidPrice  = errors.New("price must not be nil or negative")
+	ErrUnknownAsset  = errors.New("asset has no price")
+)
+
+// NewPrices creates synthetic prices from a map of asset ID to price, e.g. for scenario analysis.
+// Synthetic prices have no Data and can not be used on-chain.
+func NewPrices(list map[string]*big.Int) *Prices {
+	return &Prices{list: maps.Clone(list), synthetic: true}
+}
+
+// Synthetic reports whether the prices were not entirely produced by the oracles.
+func (p *Prices) Synthetic() bool {
+	return p.synthetic
+}
+
+// WithOverrides returns a synthetic copy of the prices with the given asset prices replaced.
+// A nil or negative price returns ErrInvalidPrice.
+func (p *Prices) WithOverrides(overrides map[string]*big.Int) (*Prices, error) {
+	list := maps.Clone(p.list)
+	if list == nil {
+		list = make(map[string]*big.Int, len(overrides))
+	}
+	for asset, price := range overrides {
+		if price == nil || price.Sign() == -1 {
+			return nil, fmt.Errorf("%w: %s of asset %s", ErrInvalidPrice, price, asset)
+		}
+		list[asset] = new(big.Int).Set(price)
+	}
+	return &Prices{list: list, minTimestamp: p.minTimestamp, synthetic: true}, nil
+}
+
+// Scale returns a synthetic copy of the prices with the asset price multiplied by a positive factor
+// rounded down, e.g. big.NewRat(7, 10) for a 30% drop. A nil or non-positive factor returns ErrInvalidFactor,
+// an asset without a price returns ErrUnknownAsset.
+func (p *Prices) Scale(asset string, factor *big.Rat) (*Prices, error) {
+	if factor == nil || factor.Sign() != 1 {
+		return nil, ErrInvalidFactor
+	}
+	price, ok := p.list[asset]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAsset, asset)
+	}
+	scaled := new(big.Int).Mul(price, factor.Num())
+	scaled.Quo(scaled, factor.Denom())
+	return p.WithOverrides(map[string]*big.Int{asset: scaled})
+}
@@ -0,0 +1,97 @@
+package price
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+func TestPrices_WithOverrides(t *testing.T) {
+	cfg := config.GetMainMainnetConfig()
+	tonAsset, usdtAsset := config.TON.ID(), config.USDT.ID()
+	data := map[string]*RawData{}
+	for _, oracle := range cfg.Oracles {
+		data[oracle.Address] = newTestRawData(cfg.Assets, time.Now().Unix(), 5_000_000_000)
+	}
+	prices, err := NewService(cfg, oracleStub(data)).GetPrices(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get prices, err: %s", err)
+	}
+
+	scaled, err := prices.Scale(tonAsset, big.NewRat(7, 10))
+	if err != nil {
+		t.Fatalf("failed to scale prices, err: %s", err)
+	}
+	if got := scaled.Get(tonAsset); got.Cmp(big.NewInt(3_500_000_000)) != 0 {
+		t.Errorf("scaled TON price want %d, got %s", 3_500_000_000, got)
+	}
+	if got := scaled.Get(usdtAsset); got.Cmp(big.NewInt(5_000_000_000)) != 0 {
+		t.Errorf("USDT price want %d, got %s", 5_000_000_000, got)
+	}
+	if !scaled.Synthetic() || scaled.Data() != nil {
+		t.Errorf("scaled prices should be synthetic without data")
+	}
+	if scaled.MinTimestamp() != prices.MinTimestamp() {
+		t.Errorf("MinTimestamp want %d, got %d", prices.MinTimestamp(), scaled.MinTimestamp())
+	}
+
+	if prices.Synthetic() || prices.Data() == nil {
+		t.Errorf("original prices should stay intact")
+	}
+	if got := prices.Get(tonAsset); got.Cmp(big.NewInt(5_000_000_000)) != 0 {
+		t.Errorf("original TON price want %d, got %s", 5_000_000_000, got)
+	}
+
+	overridden, err := scaled.WithOverrides(map[string]*big.Int{usdtAsset: big.NewInt(950_000_000)})
+	if err != nil {
+		t.Fatalf("failed to override prices, err: %s", err)
+	}
+	if got := overridden.Get(usdtAsset); got.Cmp(big.NewInt(950_000_000)) != 0 {
+		t.Errorf("overridden USDT price want %d, got %s", 950_000_000, got)
+	}
+	if got := overridden.Get(tonAsset); got.Cmp(big.NewInt(3_500_000_000)) != 0 {
+		t.Errorf("TON price want %d, got %s", 3_500_000_000, got)
+	}
+}
+
+func TestPrices_Scale_invalidFactor(t *testing.T) {
+	prices := NewPrices(map[string]*big.Int{config.TON.ID(): big.NewInt(4_000_000_000)})
+	for _, factor := range []*big.Rat{nil, new(big.Rat), big.NewRat(-1, 2)} {
+		if _, err := prices.Scale(config.TON.ID(), factor); !errors.Is(err, ErrInvalidFactor) {
+			t.Errorf("Scale by %v err want %s, got %v", factor, ErrInvalidFactor, err)
+		}
+	}
+}
+
+func TestPrices_WithOverrides_invalidPrice(t *testing.T) {
+	prices := NewPrices(map[string]*big.Int{config.TON.ID(): big.NewInt(4_000_000_000)})
+	for _, price := range []*big.Int{nil, big.NewInt(-1)} {
+		if _, err := prices.WithOverrides(map[string]*big.Int{config.TON.ID(): price}); !errors.Is(err, ErrInvalidPrice) {
+			t.Errorf("WithOverrides with %v err want %s, got %v", price, ErrInvalidPrice, err)
+		}
+	}
+}
+
+func TestPrices_Scale_unknownAsset(t *testing.T) {
+	prices := NewPrices(map[string]*big.Int{config.TON.ID(): big.NewInt(4_000_000_000)})
+	if _, err := prices.Scale(config.USDT.ID(), big.NewRat(1, 2)); !errors.Is(err, ErrUnknownAsset) {
+		t.Errorf("Scale of an asset without price err want %s, got %v", ErrUnknownAsset, err)
+	}
+}
+
+func TestNewPrices(t *testing.T) {
+	list := map[string]*big.Int{config.TON.ID(): big.NewInt(4_000_000_000)}
+	prices := NewPrices(list)
+	list[config.TON.ID()] = big.NewInt(1)
+
+	if got := prices.Get(config.TON.ID()); got.Cmp(big.NewInt(4_000_000_000)) != 0 {
+		t.Errorf("TON price want %d, got %s", 4_000_000_000, got)
+	}
+	if !prices.Synthetic() || prices.Data() != nil {
+		t.Errorf("prices from a map should be synthetic without data")
+	}
+}
@@ -0,0 +1,206 @@
+package principal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"golang.org/x/sync/semaphore"
+)
+
+var _ AccountGetter = (ton.APIClientWrapped)(nil)
+
+// DefaultFetchConcurrency is the default number of account requests a batch keeps in flight.
+const DefaultFetchConcurrency = 16
+
+// AccountGetter is the part of ton.APIClientWrapped used to read user contracts.
+type AccountGetter interface {
+	CurrentMasterchainInfo(ctx context.Context) (*ton.BlockIDExt, error)
+	GetAccount(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*tlb.Account, error)
+}
+
+// FetchResult is the user contract fetched for an address of a batch.
+type FetchResult struct {
+	// Address is the requested wallet or user contract address.
+	Address *address.Address
+	User    *UserSC
+	Err     error
+}
+
+var ErrUserFrozen = errors.New("user contract is frozen")
+
+// Fetcher reads user contracts of the service master. A contract which does not exist or is not
+// initialized yet is returned as a user with an empty position. A frozen contract may still hold debt,
+// its data is not available though, so it is reported with ErrUserFrozen.
+type Fetcher struct {
+	api         AccountGetter
+	service     *Service
+	concurrency int
+	limiter     *limiter
+}
+
+func NewFetcher(api AccountGetter, service *Service) *Fetcher {
+	return &Fetcher{api: api, service: service, concurrency: DefaultFetchConcurrency, limiter: &limiter{}}
+}
+
+// SetConcurrency limits the number of account requests a batch keeps in flight,
+// values below 1 restore DefaultFetchConcurrency.
+func (f *Fetcher) SetConcurrency(concurrency int) *Fetcher {
+	if concurrency < 1 {
+		concurrency = DefaultFetchConcurrency
+	}
+	f.concurrency = concurrency
+	return f
+}
+
+// SetRateLimit spaces account requests of all calls at least interval apart, zero disables the limit.
+// It must not be called concurrently with fetching.
+func (f *Fetcher) SetRateLimit(interval time.Duration) *Fetcher {
+	f.limiter = &limiter{interval: interval}
+	return f
+}
+
+// FetchUser returns the user contract of the wallet at the block, nil block means the current masterchain block.
+func (f *Fetcher) FetchUser(ctx context.Context, block *ton.BlockIDExt, wallet *address.Address) (*UserSC, error) {
+	userSCAddress, err := f.service.CalculateUserSCAddress(wallet)
+	if err != nil {
+		return nil, err
+	}
+	if block, err = f.block(ctx, block); err != nil {
+		return nil, err
+	}
+	return f.fetch(ctx, block, userSCAddress, wallet)
+}
+
+// FetchUserSC returns the user contract at the address at the block, nil block means the current masterchain block.
+func (f *Fetcher) FetchUserSC(ctx context.Context, block *ton.BlockIDExt, userSCAddress *address.Address) (*UserSC, error) {
+	block, err := f.block(ctx, block)
+	if err != nil {
+		return nil, err
+	}
+	return f.fetch(ctx, block, userSCAddress, nil)
+}
+
+// FetchUsers returns the user contracts of the wallets in their order, all read at the same block.
+func (f *Fetcher) FetchUsers(ctx context.Context, block *ton.BlockIDExt, wallets []*address.Address) ([]*FetchResult, error) {
+	return f.fetchAll(ctx, block, wallets, func(ctx context.Context, block *ton.BlockIDExt, wallet *address.Address) (*UserSC, error) {
+		userSCAddress, err := f.service.CalculateUserSCAddress(wallet)
+		if err != nil {
+			return nil, err
+		}
+		return f.fetch(ctx, block, userSCAddress, wallet)
+	})
+}
+
+// FetchUserSCs returns the user contracts at the addresses in their order, all read at the same block.
+func (f *Fetcher) FetchUserSCs(ctx context.Context, block *ton.BlockIDExt, userSCAddresses []*address.Address) ([]*FetchResult, error) {
+	return f.fetchAll(ctx, block, userSCAddresses, func(ctx context.Context, block *ton.BlockIDExt, userSCAddress *address.Address) (*UserSC, error) {
+		return f.fetch(ctx, block, userSCAddress, nil)
+	})
+}
+
+// fetchAll fetches every address holding sem while the request is in flight, a goroutine is only started
+// once sem is acquired. A failed address is reported in its result, the error is returned only
+// if the block can not be resolved.
+func (f *Fetcher) fetchAll(ctx context.Context, block *ton.BlockIDExt, addrs []*address.Address,
+	fetch func(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*UserSC, error)) ([]*FetchResult, error) {
+	block, err := f.block(ctx, block)
+	if err != nil {
+		return nil, err
+	}
+
+	sem := semaphore.NewWeighted(int64(f.concurrency))
+	results := make([]*FetchResult, len(addrs))
+	var wg sync.WaitGroup
+	for i, addr := range addrs {
+		result := &FetchResult{Address: addr}
+		results[i] = result
+		if result.Err = sem.Acquire(ctx, 1); result.Err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer sem.Release(1)
+			result.User, result.Err = fetch(ctx, block, addr)
+		}()
+	}
+	wg.Wait()
+	return results, nil
+}
+
+func (f *Fetcher) block(ctx context.Context, block *ton.BlockIDExt) (*ton.BlockIDExt, error) {
+	if block != nil {
+		return block, nil
+	}
+	if err := f.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	block, err := f.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info, err: %w", err)
+	}
+	return block, nil
+}
+
+// fetch reads the user contract, wallet is set as the user address of an empty position if it is known.
+func (f *Fetcher) fetch(ctx context.Context, block *ton.BlockIDExt, userSCAddress, wallet *address.Address) (*UserSC, error) {
+	if err := f.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	account, err := f.api.GetAccount(ctx, block, userSCAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user contract %s, err: %w", userSCAddress, err)
+	}
+
+	user := NewUserSC(userSCAddress)
+	switch {
+	case !account.IsActive || account.State != nil && account.State.Status == tlb.AccountStatusUninit:
+		user.userAddress = wallet
+		user.masterAddress = f.service.config.MasterAddress
+		return user, nil
+	case account.State != nil && account.State.Status == tlb.AccountStatusFrozen:
+		return nil, fmt.Errorf("%w: %s", ErrUserFrozen, userSCAddress)
+	case account.Data == nil:
+		return nil, fmt.Errorf("%w: user contract %s has no data", ErrUnknownUserLayout, userSCAddress)
+	}
+	if _, err = user.SetAccData(account.Data); err != nil {
+		return nil, fmt.Errorf("failed to decode user contract %s, err: %w", userSCAddress, err)
+	}
+	return user, nil
+}
+
+// limiter spaces the calls of wait at least interval apart.
+type limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func (l *limiter) wait(ctx context.Context) error {
+	if l.interval <= 0 {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	at := time.Now()
+	if at.Before(l.next) {
+		at = l.next
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	t := time.NewTimer(time.Until(at))
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,208 @@
+package principal
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+type stubAccountGetter struct {
+	accounts map[string]*tlb.Account
+	delay    time.Duration
+
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	requests    []time.Time
+	blocks      []*ton.BlockIDExt
+}
+
+func (g *stubAccountGetter) CurrentMasterchainInfo(context.Context) (*ton.BlockIDExt, error) {
+	return &ton.BlockIDExt{SeqNo: 42}, nil
+}
+
+func (g *stubAccountGetter) GetAccount(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*tlb.Account, error) {
+	g.mu.Lock()
+	g.inFlight++
+	g.maxInFlight = max(g.maxInFlight, g.inFlight)
+	g.requests = append(g.requests, time.Now())
+	g.blocks = append(g.blocks, block)
+	g.mu.Unlock()
+	defer func() {
+		g.mu.Lock()
+		g.inFlight--
+		g.mu.Unlock()
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(g.delay):
+	}
+	account, ok := g.accounts[addr.String()]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return account, nil
+}
+
+func newFetcherUserData(masterAddress, userAddress *address.Address) *cell.Cell {
+	principals := cell.NewDict(256)
+	_ = principals.SetIntKey(config.TON.Sha256Hash(), cell.BeginCell().MustStoreInt(1_000_000_000, 64).EndCell())
+	return cell.BeginCell().
+		MustStoreCoins(6).
+		MustStoreAddr(masterAddress).
+		MustStoreAddr(userAddress).
+		MustStoreDict(principals).
+		MustStoreInt(0, 64).
+		MustStoreDict(nil).
+		MustStoreMaybeRef(nil).
+		MustStoreMaybeRef(nil).
+		EndCell()
+}
+
+func TestFetcher_FetchUser(t *testing.T) {
+	cfg := config.GetMainMainnetConfig()
+	service := NewService(cfg)
+	wallet := address.MustParseAddr("UQBlB6eFlc-to_YqCabuBtSWFyY8uYm7Y6G39ADdiKvzi389")
+	userSCAddress := address.MustParseAddr("EQBHgCET1SV9Y2_dbnJBDczB4eIUvelocCsuQf3tAelZCniF")
+	emptyWallet := address.MustParseAddr("EQC8rUZqR_pWV1BylWUlPNBzyiTYVoBEmQkMIQDZXICfnuRr")
+	emptyUserSCAddress, _ := service.CalculateUserSCAddress(emptyWallet)
+
+	api := &stubAccountGetter{accounts: map[string]*tlb.Account{
+		userSCAddress.String():      {IsActive: true, Data: newFetcherUserData(cfg.MasterAddress, wallet)},
+		emptyUserSCAddress.String(): {IsActive: false},
+	}}
+	fetcher := NewFetcher(api, service)
+
+	user, err := fetcher.FetchUser(context.Background(), nil, wallet)
+	if err != nil {
+		t.Fatalf("FetchUser err: %s", err)
+	}
+	if !user.Address().Equals(userSCAddress) || !user.UserAddress().Equals(wallet) {
+		t.Errorf("FetchUser addresses want %s/%s, got %s/%s", userSCAddress, wallet, user.Address(), user.UserAddress())
+	}
+	if user.Principal(config.TON.ID()).Int64() != 1_000_000_000 {
+		t.Errorf("FetchUser TON principal want %d, got %s", 1_000_000_000, user.Principal(config.TON.ID()))
+	}
+	if api.blocks[0] == nil || api.blocks[0].SeqNo != 42 {
+		t.Errorf("FetchUser with nil block should read at the current block")
+	}
+
+	empty, err := fetcher.FetchUser(context.Background(), &ton.BlockIDExt{SeqNo: 7}, emptyWallet)
+	if err != nil {
+		t.Fatalf("FetchUser of an uninitialized contract err: %s", err)
+	}
+	if len(empty.Principals()) != 0 || !empty.UserAddress().Equals(emptyWallet) || !empty.MasterAddress().Equals(cfg.MasterAddress) {
+		t.Errorf("FetchUser of an uninitialized contract should return an empty position of the wallet")
+	}
+	if api.blocks[1].SeqNo != 7 {
+		t.Errorf("FetchUser block want %d, got %d", 7, api.blocks[1].SeqNo)
+	}
+
+	if _, err := fetcher.FetchUserSC(context.Background(), nil, emptyWallet); err == nil {
+		t.Errorf("FetchUserSC should fail when the account can not be read")
+	}
+
+	uninit := &tlb.Account{IsActive: true, State: &tlb.AccountState{AccountStorage: tlb.AccountStorage{Status: tlb.AccountStatusUninit}}}
+	api.accounts[userSCAddress.String()] = uninit
+	if user, err := fetcher.FetchUserSC(context.Background(), nil, userSCAddress); err != nil || len(user.Principals()) != 0 {
+		t.Errorf("FetchUserSC of an uninitialized contract want an empty position, got %v, err: %v", user, err)
+	}
+
+	frozen := &tlb.Account{IsActive: true, State: &tlb.AccountState{AccountStorage: tlb.AccountStorage{Status: tlb.AccountStatusFrozen}}}
+	api.accounts[userSCAddress.String()] = frozen
+	if _, err := fetcher.FetchUserSC(context.Background(), nil, userSCAddress); !errors.Is(err, ErrUserFrozen) {
+		t.Errorf("FetchUserSC of a frozen contract err want %s, got %v", ErrUserFrozen, err)
+	}
+
+	api.accounts[userSCAddress.String()] = &tlb.Account{IsActive: true, Data: cell.BeginCell().MustStoreUInt(1, 8).EndCell()}
+	if _, err := fetcher.FetchUserSC(context.Background(), nil, userSCAddress); !errors.Is(err, ErrUnknownUserLayout) {
+		t.Errorf("FetchUserSC of malformed data err want %s, got %v", ErrUnknownUserLayout, err)
+	}
+}
+
+func TestFetcher_FetchUsers(t *testing.T) {
+	cfg := config.GetMainMainnetConfig()
+	service := NewService(cfg)
+	api := &stubAccountGetter{accounts: map[string]*tlb.Account{}, delay: 10 * time.Millisecond}
+
+	wallets := make([]*address.Address, 20)
+	for i := range wallets {
+		wallets[i] = address.NewAddress(0, 0, append(make([]byte, 31), byte(i)))
+		if i == 5 {
+			continue
+		}
+		userSCAddress, err := service.CalculateUserSCAddress(wallets[i])
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		api.accounts[userSCAddress.String()] = &tlb.Account{IsActive: true, Data: newFetcherUserData(cfg.MasterAddress, wallets[i])}
+	}
+
+	results, err := NewFetcher(api, service).SetConcurrency(4).FetchUsers(context.Background(), nil, wallets)
+	if err != nil {
+		t.Fatalf("FetchUsers err: %s", err)
+	}
+	for i, result := range results {
+		if !result.Address.Equals(wallets[i]) {
+			t.Fatalf("results should keep the order of the wallets")
+		}
+		if i == 5 {
+			if result.Err == nil {
+				t.Errorf("result %d should report the failed request", i)
+			}
+			continue
+		}
+		if result.Err != nil || !result.User.UserAddress().Equals(wallets[i]) {
+			t.Errorf("result %d want user of %s, got %v, err: %v", i, wallets[i], result.User, result.Err)
+		}
+	}
+	if api.maxInFlight > 4 {
+		t.Errorf("in flight requests want at most %d, got %d", 4, api.maxInFlight)
+	}
+	for _, block := range api.blocks {
+		if block.SeqNo != 42 {
+			t.Fatalf("all users should be read at the same block")
+		}
+	}
+}
+
+func TestFetcher_rateLimit(t *testing.T) {
+	api := &stubAccountGetter{accounts: map[string]*tlb.Account{}}
+	userSCAddresses := make([]*address.Address, 5)
+	for i := range userSCAddresses {
+		userSCAddresses[i] = address.NewAddress(0, 0, append(make([]byte, 31), byte(i)))
+		api.accounts[userSCAddresses[i].String()] = &tlb.Account{IsActive: false}
+	}
+
+	interval := 20 * time.Millisecond
+	fetcher := NewFetcher(api, NewService(config.GetMainMainnetConfig())).SetRateLimit(interval)
+	if _, err := fetcher.FetchUserSCs(context.Background(), &ton.BlockIDExt{}, userSCAddresses); err != nil {
+		t.Fatalf("FetchUserSCs err: %s", err)
+	}
+	if elapsed := api.requests[len(api.requests)-1].Sub(api.requests[0]); elapsed < 4*interval-time.Millisecond {
+		t.Errorf("5 requests should take at least %s, got %s", 4*interval, elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err := fetcher.FetchUserSCs(ctx, &ton.BlockIDExt{}, userSCAddresses)
+	if err != nil {
+		t.Fatalf("FetchUserSCs err: %s", err)
+	}
+	for _, result := range results {
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("cancelled fetch err want %s, got %v", context.Canceled, result.Err)
+		}
+	}
+}
@@ -38,6 +38,11 @@ type priceProvider interface {
 	Get(asset string) *big.Int
 }
 
+// Health is an aggregate of the user position valued at oracle prices, all values are scaled like prices.
+//
+// TotalLimit is the collateral value weighted by the liquidation thresholds. The contract allows liquidation
+// as soon as TotalDebt > TotalLimit, so a position with TotalDebt == TotalLimit is still safe.
+// Every ratio and distance below is derived from this single check.
 type Health struct {
 	GreatestCollateralValue *big.Int
 	GreatestCollateralAsset *big.Int
@@ -109,6 +114,7 @@ func (h *Health) IsBadDebt(liquidationBonus, liquidationBonusScale *big.Int) boo
 	return new(big.Int).Mul(h.TotalSupply, liquidationBonusScale).Cmp(new(big.Int).Mul(h.TotalDebt, liquidationBonus)) == -1
 }
 
+// IsLiquidatable reports whether the contract accepts a liquidation of the position, i.e. TotalDebt > TotalLimit.
 func (h *Health) IsLiquidatable() bool {
 	return h.TotalLimit.Cmp(h.TotalDebt) == -1
 }
@@ -155,22 +161,64 @@ func (s *Service) AggregatedBalances(user UserBalancer, assets assetManager, pri
 	return health.TotalSupply, health.TotalDebt
 }
 
-func (s *Service) PredictHealthFactor(user UserBalancer, assets assetManager, prices priceProvider, asset string, amount *big.Int) float64 {
+// PredictHealth calculates the health of the position after its principal of the asset is changed by amount.
+func (s *Service) PredictHealth(user UserBalancer, assets assetManager, prices priceProvider, asset string, amount *big.Int) *Health {
 	if asset != "" || (amount != nil && amount.Sign() != 0) {
 		user = user.ChangePrincipal(asset, amount)
 	}
-	health := s.CalculateHealth(user, assets, prices)
+	return s.CalculateHealth(user, assets, prices)
+}
 
-	return health.Factor()
+func (s *Service) PredictHealthFactor(user UserBalancer, assets assetManager, prices priceProvider, asset string, amount *big.Int) float64 {
+	return s.PredictHealth(user, assets, prices, asset, amount).Factor()
 }
 
+// Factor returns ExactFactor clamped to [0, 1], 1 for a position without debt and 0 for a liquidatable one.
 func (h *Health) Factor() float64 {
+	factor := h.ExactFactor()
+	if factor == nil {
+		return 0
+	}
+	hf, _ := factor.Float64()
+	return math.Min(math.Max(0, hf), 1)
+}
+
+// ExactFactor returns 1 - TotalDebt/TotalLimit. It is 1 without debt, 0 at the liquidation boundary
+// and negative once the position is liquidatable, showing how far the debt is over the limit.
+// It is nil if there is debt but no limit at all.
+func (h *Health) ExactFactor() *big.Rat {
+	debtRatio := h.DebtRatio()
+	if debtRatio == nil {
+		return nil
+	}
+	return debtRatio.Sub(big.NewRat(1, 1), debtRatio)
+}
+
+// DebtRatio returns TotalDebt/TotalLimit, the position is liquidatable once it is greater than 1.
+// It is nil if there is debt but no limit at all.
+func (h *Health) DebtRatio() *big.Rat {
+	if h.TotalDebt.Sign() == 0 {
+		return new(big.Rat)
+	}
 	if h.TotalLimit.Sign() <= 0 {
-		return 1
+		return nil
 	}
+	return new(big.Rat).SetFrac(h.TotalDebt, h.TotalLimit)
+}
+
+// LimitRatio returns TotalLimit/TotalDebt, the position is liquidatable once it is less than 1.
+// It is nil if there is no debt.
+func (h *Health) LimitRatio() *big.Rat {
+	if h.TotalDebt.Sign() == 0 {
+		return nil
+	}
+	return new(big.Rat).SetFrac(h.TotalLimit, h.TotalDebt)
+}
 
-	hf, _ := new(big.Rat).Quo(new(big.Rat).SetInt(h.TotalDebt), new(big.Rat).SetInt(h.TotalLimit)).Float64()
-	return math.Min(math.Max(0, 1-hf), 1)
+// Buffer returns TotalLimit - TotalDebt, the USD value the debt may grow by, or the weighted collateral
+// may lose, before the position becomes liquidatable. It is negative for a liquidatable position.
+func (h *Health) Buffer() *big.Int {
+	return new(big.Int).Sub(h.TotalLimit, h.TotalDebt)
 }
 
 func (s *Service) CalculateLiquidationData(user UserBalancer, assets assetManager, prices priceProvider) (health *Health, liquidationAmount, collateralAmount *big.Int, ok bool) {
@@ -179,38 +227,11 @@ func (s *Service) CalculateLiquidationData(user UserBalancer, assets assetManage
 		return health, nil, nil, false
 	}
 
-	calculator := NewCalculator(assets, prices, s.config)
-	loanAssetString := health.GreatestLoanAsset.String()
-	loanAssetConfig := assets.Config(loanAssetString)
-
-	collateralAssetString := health.GreatestCollateralAsset.String()
-	collateralAssetConfig := assets.Config(collateralAssetString)
-
-	allowedCollateralValue := health.GreatestCollateralValue
-	if !health.IsBadDebt(collateralAssetConfig.LiquidationBonus, s.config.MasterParams.AssetLiquidationBonusScale) {
-		allowedCollateralValue = bigIntMin(allowedCollateralValue, bigIntMax(
-			new(big.Int).Div(allowedCollateralValue, big.NewInt(2)), s.config.MasterParams.CollateralWorthThreshold))
-	}
-
-	liquidationValue := bigIntMin(health.GreatestLoanValue, mulDiv(
-		allowedCollateralValue,
-		s.config.MasterParams.AssetLiquidationBonusScale,
-		collateralAssetConfig.LiquidationBonus),
+	liquidation := s.liquidatePair(health, NewCalculator(assets, prices, s.config), assets,
+		health.GreatestLoanAsset.String(), health.GreatestLoanValue,
+		health.GreatestCollateralAsset.String(), health.GreatestCollateralValue,
 	)
-	collateralValue := mulDiv(liquidationValue, collateralAssetConfig.LiquidationBonus, s.config.MasterParams.AssetLiquidationBonusScale)
-	collateralAmount = calculator.BalanceFromValue(collateralValue, collateralAssetString)
-
-	liquidationValue = mulDiv(
-		liquidationValue,
-		s.config.MasterParams.AssetLiquidationReserveFactorScale,
-		new(big.Int).Sub(
-			s.config.MasterParams.AssetLiquidationReserveFactorScale,
-			loanAssetConfig.LiquidationReserveFactor,
-		),
-	)
-	liquidationAmount = calculator.BalanceFromValue(liquidationValue, loanAssetString)
-
-	return health, liquidationAmount, collateralAmount, true
+	return health, liquidation.LiquidationAmount, liquidation.CollateralAmount, true
 }
 
 func (s *Service) CalculateUserSCAddress(userAddress *address.Address) (*address.Address, error) {
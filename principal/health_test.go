@@ -156,6 +156,52 @@ func TestService_CalculateLiquidationData(t *testing.T) {
 	}
 }
 
+func TestHealth_ratios(t *testing.T) {
+	tests := []struct {
+		name         string
+		debt, limit  int64
+		factor       float64
+		exactFactor  *big.Rat
+		debtRatio    *big.Rat
+		limitRatio   *big.Rat
+		buffer       int64
+		liquidatable bool
+	}{
+		{"no debt", 0, 100, 1, big.NewRat(1, 1), big.NewRat(0, 1), nil, 100, false},
+		{"healthy", 25, 100, 0.75, big.NewRat(3, 4), big.NewRat(1, 4), big.NewRat(4, 1), 75, false},
+		{"boundary", 100, 100, 0, big.NewRat(0, 1), big.NewRat(1, 1), big.NewRat(1, 1), 0, false},
+		{"over limit", 150, 100, 0, big.NewRat(-1, 2), big.NewRat(3, 2), big.NewRat(2, 3), -50, true},
+		{"no limit", 10, 0, 0, nil, nil, big.NewRat(0, 1), -10, true},
+		{"empty", 0, 0, 1, big.NewRat(1, 1), big.NewRat(0, 1), nil, 0, false},
+	}
+	equal := func(a, b *big.Rat) bool {
+		return (a == nil && b == nil) || (a != nil && b != nil && a.Cmp(b) == 0)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Health{TotalDebt: big.NewInt(tt.debt), TotalLimit: big.NewInt(tt.limit)}
+			if got := h.Factor(); got != tt.factor {
+				t.Errorf("Factor want %f, got %f", tt.factor, got)
+			}
+			if got := h.ExactFactor(); !equal(got, tt.exactFactor) {
+				t.Errorf("ExactFactor want %v, got %v", tt.exactFactor, got)
+			}
+			if got := h.DebtRatio(); !equal(got, tt.debtRatio) {
+				t.Errorf("DebtRatio want %v, got %v", tt.debtRatio, got)
+			}
+			if got := h.LimitRatio(); !equal(got, tt.limitRatio) {
+				t.Errorf("LimitRatio want %v, got %v", tt.limitRatio, got)
+			}
+			if got := h.Buffer(); got.Cmp(big.NewInt(tt.buffer)) != 0 {
+				t.Errorf("Buffer want %d, got %s", tt.buffer, got)
+			}
+			if got := h.IsLiquidatable(); got != tt.liquidatable {
+				t.Errorf("IsLiquidatable want %t, got %t", tt.liquidatable, got)
+			}
+		})
+	}
+}
+
 func getAssetsService(t *testing.T, cfg *config.Config) *asset.Parser {
 	parser := asset.NewParser(cfg)
 	assetsDataCell, _ := config.GetCellFromHex("b5ee9c7241020b0100028300020120010202012003040201200506020120070800cabf895668e908644f30322b997de8faaafc21f05aa52f8982f042dac1fe0b4d09d0000000ba85859fd4000000bc4aa2bec00007de36d82a0c2b00008ca20ad5529a671f51b90005dd9121feaa18000000000000000000000000000000000000000000000000020120090a00cabf8a9006bd3fb03d355daeeff93b24be90afaa6e3ca0073ff5720f8a852c933278000000c319365650000000ca80c5df17000018070112552e00000c648b3cc706671f52a20000099c5958d4bd00000000000000000000000000000000000000000006b6c000c9bf748433fcbcc1ac75e54798fb9cdfd8d368b8d6ae3092f4c291cf8465590f7b140000017b4e135da2000001825d6af8ea00191e1744cd410e000950b177ace73ece3ea1b6000cdbbf61a9a52c00000000000000000000000000000000000000000000000100c9bf6627c5eaf750e15e689006a18f136130fa2b6874a62e57f9c529bc43cfae49ce000001753bedb04e00000178963de082000f1b122b25f50c0005a70bd5bccffece3ea5440007923142840bf200000000000000000000000000000000000000000000000100c9bf47b22d8d0a21004209a3eeb54d9c61d63c8ef5dbc1a701ddc4311c1cacb03f8c000001580ac5064400000361ca46bd9e00000000b462d90c000000008911a282ce3ea438000000029a968de000000000000000000000000000000000000000000000000100c9bf670f2d046c32f2b194958abd36b7c71cd118ec635f0990ceac863e9350f1de6600000159b144454c00000190922227de00000008f6f1847400000005a845a7b0ce3ea43800000006bb1b06540000000000000000000000000000000000000000000000017909da03")
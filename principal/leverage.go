@@ -0,0 +1,289 @@
+package principal
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/evaafi/evaa-go-sdk/asset"
+)
+
+var ErrInvalidLeverage = errors.New("invalid leverage parameters")
+
+// DefaultLeverageSteps is the default LeverageParameters.MaxSteps.
+const DefaultLeverageSteps = 10
+
+const secondsPerYear = 365 * 24 * 60 * 60
+
+// SwapModel returns the amount of the to asset received for the amount of the from asset.
+type SwapModel func(from, to string, amount *big.Int) *big.Int
+
+// NewPriceSwap swaps at the oracle prices minus slippage in basis points.
+func (s *Service) NewPriceSwap(assets assetManager, prices priceProvider, slippage uint64) SwapModel {
+	calculator := NewCalculator(assets, prices, s.config)
+	return func(from, to string, amount *big.Int) *big.Int {
+		value := mulDiv(calculator.ValueFromBalance(amount, from), big.NewInt(10_000-int64(min(slippage, 10_000))), big.NewInt(10_000))
+		return calculator.BalanceFromValue(value, to)
+	}
+}
+
+// LeverageParameters describe a loop of borrowing the debt asset, swapping it to the collateral asset
+// and supplying it back.
+type LeverageParameters struct {
+	Collateral string
+	Debt       string
+	// Amount is the collateral amount supplied before the first loop, it may be zero for an existing position.
+	Amount *big.Int
+	// TargetLeverage limits TotalSupply / (TotalSupply - TotalDebt), nil means no limit.
+	// When deleveraging, the plan stops once the leverage is at or below it, nil means unwinding the whole debt.
+	TargetLeverage *big.Rat
+	// TargetHealth is the least health factor a borrow may leave, see Health.ExactFactor, nil means the borrow limit only.
+	TargetHealth *big.Rat
+	// MaxSteps limits the number of loops, zero means DefaultLeverageSteps.
+	MaxSteps int
+	Swap     SwapModel
+}
+
+// LeverageStep is a single supply, borrow, withdraw or repay of the plan.
+type LeverageStep struct {
+	Action HealthAction
+	Asset  string
+	Amount *big.Int
+	// PrincipalChange is the change of the asset principal for UserBalancer.ChangePrincipal.
+	PrincipalChange *big.Int
+}
+
+// LeveragePlan is the sequence of steps and the position it results in.
+type LeveragePlan struct {
+	Steps      []*LeverageStep
+	Principals map[string]*big.Int
+	User       UserBalancer
+	Health     *Health
+	// Leverage is TotalSupply / (TotalSupply - TotalDebt), nil if the position has no equity.
+	Leverage *big.Rat
+	// NetAPR is the yearly return on equity at the current asset rates, annualized linearly.
+	// It is nil if the position has no equity.
+	NetAPR *big.Rat
+	// NetAPY is the yearly return on equity at the current asset rates with the interest of every supply
+	// and borrow compounded each second. It is nil if the position has no equity.
+	NetAPY *big.Rat
+}
+
+// PlanLeverage supplies Amount of the collateral and then loops borrowing the debt asset as much as the targets
+// and the borrow limit allow, swapping it and supplying the result, until a loop adds nothing or MaxSteps is reached.
+func (s *Service) PlanLeverage(user UserBalancer, assets assetManager, prices priceProvider, params *LeverageParameters) (*LeveragePlan, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	l := &leverager{service: s, assets: assets, prices: prices, calculator: NewCalculator(assets, prices, s.config), user: user}
+
+	if params.Amount != nil && params.Amount.Sign() == 1 {
+		l.apply(ActionSupply, params.Collateral, params.Amount)
+	}
+	for i := 0; i < params.maxSteps(); i++ {
+		limit := s.CalculateMaximumWithdrawAmount(l.user, assets, prices, params.Debt)
+		if limit.Sign() <= 0 {
+			break
+		}
+		borrow := searchAmount(limit, true, func(amount *big.Int) bool {
+			borrowed := l.try(params.Debt, new(big.Int).Neg(amount))
+			if params.TargetHealth != nil && !reachesFactor(s.CalculateHealth(borrowed, assets, prices), params.TargetHealth) {
+				return false
+			}
+			if params.TargetLeverage == nil {
+				return true
+			}
+			swapped := params.Swap(params.Debt, params.Collateral, amount)
+			changed, _ := changeBalance(borrowed, l.calculator, assets, params.Collateral, swapped)
+			leverage := leverageOf(s.CalculateHealth(changed, assets, prices))
+			return leverage != nil && leverage.Cmp(params.TargetLeverage) <= 0
+		})
+		swapped := params.Swap(params.Debt, params.Collateral, borrow)
+		if borrow.Sign() == 0 || swapped.Sign() == 0 {
+			break
+		}
+		l.apply(ActionBorrow, params.Debt, borrow)
+		l.apply(ActionSupply, params.Collateral, swapped)
+	}
+	return l.plan(), nil
+}
+
+// PlanDeleverage reverses PlanLeverage: it loops withdrawing the collateral as much as the borrow limit allows,
+// swapping it to the debt asset and repaying, until the leverage is at TargetLeverage or the debt is repaid.
+// Amount and TargetHealth are not used.
+func (s *Service) PlanDeleverage(user UserBalancer, assets assetManager, prices priceProvider, params *LeverageParameters) (*LeveragePlan, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	l := &leverager{service: s, assets: assets, prices: prices, calculator: NewCalculator(assets, prices, s.config), user: user}
+
+	for i := 0; i < params.maxSteps(); i++ {
+		balance := l.user.Balance(params.Debt, assets.Data(params.Debt), false, nil)
+		if balance.Sign() != -1 {
+			break
+		}
+		debt := new(big.Int).Neg(balance)
+		if params.TargetLeverage != nil {
+			if leverage := leverageOf(s.CalculateHealth(l.user, assets, prices)); leverage != nil && leverage.Cmp(params.TargetLeverage) <= 0 {
+				break
+			}
+		}
+
+		limit := s.CalculateMaximumWithdrawAmount(l.user, assets, prices, params.Collateral)
+		if limit.Sign() <= 0 {
+			break
+		}
+		// the least withdrawal which repays the debt or brings the leverage down to the target
+		withdraw := limit
+		if params.Swap(params.Collateral, params.Debt, limit).Cmp(debt) >= 0 {
+			withdraw = searchAmount(limit, false, func(amount *big.Int) bool {
+				return params.Swap(params.Collateral, params.Debt, amount).Cmp(debt) >= 0
+			})
+		}
+		if params.TargetLeverage != nil {
+			withdraw = searchAmount(withdraw, false, func(amount *big.Int) bool {
+				if amount.Sign() == 0 {
+					return false
+				}
+				withdrawn := l.try(params.Collateral, new(big.Int).Neg(amount))
+				repaid, _ := changeBalance(withdrawn, l.calculator, assets, params.Debt, params.Swap(params.Collateral, params.Debt, amount))
+				leverage := leverageOf(s.CalculateHealth(repaid, assets, prices))
+				return leverage != nil && leverage.Cmp(params.TargetLeverage) <= 0
+			})
+		}
+
+		swapped := params.Swap(params.Collateral, params.Debt, withdraw)
+		if withdraw.Sign() == 0 || swapped.Sign() == 0 {
+			break
+		}
+		l.apply(ActionWithdraw, params.Collateral, withdraw)
+		l.apply(ActionRepay, params.Debt, swapped)
+	}
+	return l.plan(), nil
+}
+
+func (p *LeverageParameters) validate() error {
+	if p.Collateral == "" || p.Debt == "" || p.Collateral == p.Debt {
+		return errors.Join(ErrInvalidLeverage, errors.New("collateral and debt assets must differ"))
+	}
+	if p.Swap == nil {
+		return errors.Join(ErrInvalidLeverage, errors.New("swap model is required"))
+	}
+	return nil
+}
+
+func (p *LeverageParameters) maxSteps() int {
+	if p.MaxSteps <= 0 {
+		return DefaultLeverageSteps
+	}
+	return p.MaxSteps
+}
+
+// leverager keeps the user position while the plan is being built.
+type leverager struct {
+	service    *Service
+	assets     assetManager
+	prices     priceProvider
+	calculator *Calculator
+	user       UserBalancer
+	steps      []*LeverageStep
+}
+
+func (l *leverager) try(asset string, delta *big.Int) UserBalancer {
+	user, _ := changeBalance(l.user, l.calculator, l.assets, asset, delta)
+	return user
+}
+
+func (l *leverager) apply(action HealthAction, asset string, amount *big.Int) {
+	delta := amount
+	if action == ActionWithdraw || action == ActionBorrow {
+		delta = new(big.Int).Neg(amount)
+	}
+	var principalChange *big.Int
+	l.user, principalChange = changeBalance(l.user, l.calculator, l.assets, asset, delta)
+	l.steps = append(l.steps, &LeverageStep{Action: action, Asset: asset, Amount: amount, PrincipalChange: principalChange})
+}
+
+func (l *leverager) plan() *LeveragePlan {
+	health := l.service.CalculateHealth(l.user, l.assets, l.prices)
+	principals := make(map[string]*big.Int)
+	for asset := range l.assets.Assets() {
+		if principal := l.user.Principal(asset); principal.Sign() != 0 {
+			principals[asset] = principal
+		}
+	}
+	plan := &LeveragePlan{
+		Steps:      l.steps,
+		Principals: principals,
+		User:       l.user,
+		Health:     health,
+		Leverage:   leverageOf(health),
+	}
+	plan.NetAPR, plan.NetAPY = l.netReturn(health)
+	return plan
+}
+
+// netReturn sums the yearly interest of every position at the per-second rates of the asset utilization
+// and divides it by the equity, linearly for APR and compounding each second for APY.
+func (l *leverager) netReturn(health *Health) (apr, apy *big.Rat) {
+	equity := new(big.Int).Sub(health.TotalSupply, health.TotalDebt)
+	if equity.Sign() <= 0 {
+		return nil, nil
+	}
+
+	factorScale := l.service.config.MasterParams.FactorScale
+	linear, compounded := new(big.Int), new(big.Float).SetPrec(compoundPrec)
+	for a := range l.assets.Assets() {
+		balance := l.user.Balance(a, l.assets.Data(a), false, nil)
+		if balance.Sign() == 0 {
+			continue
+		}
+		supplyInterest, borrowInterest := asset.CalculateInterest(l.assets.Data(a), l.assets.Config(a))
+		interest := supplyInterest
+		if balance.Sign() == -1 {
+			interest = new(big.Int).Neg(borrowInterest)
+		}
+		value := l.calculator.ValueFromBalance(new(big.Int).Abs(balance), a)
+		linear.Add(linear, new(big.Int).Mul(value, interest))
+		compounded.Add(compounded, new(big.Float).Mul(new(big.Float).SetInt(value), compound(interest, factorScale, secondsPerYear)))
+	}
+	linear.Mul(linear, big.NewInt(secondsPerYear))
+	apr = new(big.Rat).SetFrac(linear, new(big.Int).Mul(equity, factorScale))
+	apy, _ = compounded.Quo(compounded, new(big.Float).SetInt(equity)).Rat(nil)
+	return apr, apy
+}
+
+// compoundPrec is the big.Float precision of compounded interest, enough to keep the yearly growth
+// of a per-second rate exact to far more digits than a price has.
+const compoundPrec = 256
+
+// compound returns the growth (1 + interest / scale)^seconds - 1, interest may be negative for a borrow.
+func compound(interest, scale *big.Int, seconds int64) *big.Float {
+	rate := new(big.Float).SetPrec(compoundPrec).SetInt(new(big.Int).Abs(interest))
+	rate.Quo(rate, new(big.Float).SetInt(scale))
+	base := new(big.Float).SetPrec(compoundPrec).Add(big.NewFloat(1), rate)
+	growth := new(big.Float).SetPrec(compoundPrec).SetInt64(1)
+	for ; seconds > 0; seconds >>= 1 {
+		if seconds&1 == 1 {
+			growth.Mul(growth, base)
+		}
+		base.Mul(base, base)
+	}
+	growth.Sub(growth, big.NewFloat(1))
+	if interest.Sign() == -1 {
+		growth.Neg(growth)
+	}
+	return growth
+}
+
+func leverageOf(health *Health) *big.Rat {
+	equity := new(big.Int).Sub(health.TotalSupply, health.TotalDebt)
+	if equity.Sign() <= 0 {
+		return nil
+	}
+	return new(big.Rat).SetFrac(health.TotalSupply, equity)
+}
+
+func reachesFactor(health *Health, target *big.Rat) bool {
+	factor := health.ExactFactor()
+	return factor != nil && factor.Cmp(target) >= 0
+}
@@ -0,0 +1,116 @@
+package principal
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+func TestCompound(t *testing.T) {
+	scale := big.NewInt(1e12)
+	for _, interest := range []int64{1000, -1000, 0} {
+		got, _ := compound(big.NewInt(interest), scale, secondsPerYear).Float64()
+		want := math.Expm1(secondsPerYear * math.Log1p(math.Abs(float64(interest))/1e12))
+		if interest < 0 {
+			want = -want
+		}
+		if math.Abs(got-want) > 1e-12 {
+			t.Errorf("compound of %d want %g, got %g", interest, want, got)
+		}
+	}
+}
+
+func TestService_PlanLeverage(t *testing.T) {
+	cfg, parser, service := newFixture(t)
+	tonAsset, usdtAsset := config.TON.ID(), config.USDT.ID()
+	prices := fixturePrices(5000000000)
+	prices[usdtAsset] = big.NewInt(1000000000)
+	params := &LeverageParameters{
+		Collateral:     tonAsset,
+		Debt:           usdtAsset,
+		Amount:         big.NewInt(1_000_000_000_000),
+		TargetLeverage: big.NewRat(5, 2),
+		TargetHealth:   big.NewRat(1, 10),
+		Swap:           service.NewPriceSwap(parser, prices, 30),
+	}
+
+	plan, err := service.PlanLeverage(NewUserSC(nil), parser, prices, params)
+	if err != nil {
+		t.Fatalf("failed to plan leverage, err: %s", err)
+	}
+	if len(plan.Steps) < 3 || plan.Steps[0].Action != ActionSupply || plan.Steps[1].Action != ActionBorrow || plan.Steps[2].Action != ActionSupply {
+		t.Fatalf("steps want supply, borrow, supply..., got %d steps", len(plan.Steps))
+	}
+	user := UserBalancer(NewUserSC(nil))
+	for _, step := range plan.Steps {
+		user = user.ChangePrincipal(step.Asset, step.PrincipalChange)
+	}
+	for asset, principal := range plan.Principals {
+		if user.Principal(asset).Cmp(principal) != 0 {
+			t.Errorf("principal of %s want %s, got %s", cfg.Assets[asset].Name, principal, user.Principal(asset))
+		}
+	}
+	if plan.Leverage == nil || plan.Leverage.Cmp(params.TargetLeverage) > 0 || plan.Leverage.Cmp(big.NewRat(2, 1)) < 0 {
+		t.Errorf("Leverage want up to %s, got %v", params.TargetLeverage.FloatString(2), plan.Leverage)
+	}
+	if !reachesFactor(plan.Health, params.TargetHealth) {
+		t.Errorf("health factor %s is below the target", plan.Health.ExactFactor().FloatString(4))
+	}
+	if plan.NetAPR == nil {
+		t.Fatalf("NetAPR is nil")
+	}
+	// the USDT borrow interest exceeds the TON supply interest at the fixture utilization
+	if want, _ := new(big.Rat).SetString("-10318072168813721031/62220009917687500000"); plan.NetAPR.Cmp(want) != 0 {
+		t.Errorf("NetAPR want %s, got %s", want.FloatString(8), plan.NetAPR.FloatString(8))
+	}
+	if plan.NetAPY == nil || plan.NetAPY.FloatString(12) != "-0.177552032735" {
+		t.Errorf("NetAPY want %s, got %v", "-0.177552032735", plan.NetAPY)
+	}
+
+	// the borrow limit of TON stops the loop before a high target leverage
+	unlimited, err := service.PlanLeverage(NewUserSC(nil), parser, prices, &LeverageParameters{
+		Collateral: tonAsset, Debt: usdtAsset, Amount: params.Amount, MaxSteps: 30, Swap: params.Swap,
+	})
+	if err != nil {
+		t.Fatalf("failed to plan leverage, err: %s", err)
+	}
+	if unlimited.Leverage.Cmp(plan.Leverage) <= 0 {
+		t.Errorf("leverage without targets %s should exceed %s", unlimited.Leverage.FloatString(2), plan.Leverage.FloatString(2))
+	}
+	if available := service.GetAvailableToBorrow(unlimited.User, parser, prices); available.Sign() < 0 {
+		t.Errorf("plan should stay within the borrow limit, available %s", available)
+	}
+
+	deleverage, err := service.PlanDeleverage(plan.User, parser, prices, &LeverageParameters{
+		Collateral: tonAsset, Debt: usdtAsset, Swap: params.Swap,
+	})
+	if err != nil {
+		t.Fatalf("failed to plan deleverage, err: %s", err)
+	}
+	if len(deleverage.Steps) == 0 || deleverage.Steps[0].Action != ActionWithdraw || deleverage.Steps[1].Action != ActionRepay {
+		t.Fatalf("steps want withdraw, repay..., got %d steps", len(deleverage.Steps))
+	}
+	if debt := deleverage.User.Balance(usdtAsset, parser.Data(usdtAsset), false, nil); debt.Sign() < 0 {
+		t.Errorf("deleverage should repay the debt, left %s", debt)
+	}
+	if deleverage.Health.TotalDebt.Sign() != 0 {
+		t.Errorf("deleveraged position want no debt, got %s", deleverage.Health.TotalDebt)
+	}
+
+	partial, err := service.PlanDeleverage(plan.User, parser, prices, &LeverageParameters{
+		Collateral: tonAsset, Debt: usdtAsset, TargetLeverage: big.NewRat(3, 2), Swap: params.Swap,
+	})
+	if err != nil {
+		t.Fatalf("failed to plan deleverage, err: %s", err)
+	}
+	if partial.Leverage.Cmp(big.NewRat(3, 2)) > 0 || partial.Health.TotalDebt.Sign() == 0 {
+		t.Errorf("partial deleverage want leverage at most 1.5 with some debt, got %s", partial.Leverage.FloatString(4))
+	}
+
+	if _, err := service.PlanLeverage(NewUserSC(nil), parser, prices, &LeverageParameters{Collateral: tonAsset, Debt: tonAsset}); !errors.Is(err, ErrInvalidLeverage) {
+		t.Errorf("error want %s, got %v", ErrInvalidLeverage, err)
+	}
+}
@@ -0,0 +1,150 @@
+package principal
+
+import (
+	"math/big"
+	"sort"
+)
+
+// GasCost returns the USD value, scaled like prices, a liquidator spends on fees to liquidate the pair.
+type GasCost func(loanAsset, collateralAsset string) *big.Int
+
+// LiquidationOptions describe the liquidator.
+type LiquidationOptions struct {
+	// Balances are the loan asset amounts the liquidator can send, nil means unlimited.
+	// An asset missing from non-nil Balances can not be used.
+	Balances map[string]*big.Int
+	// GasCost is subtracted from the profit of every candidate, nil means free.
+	GasCost GasCost
+}
+
+// LiquidationCandidate is a liquidation of a single loan and collateral pair, values are scaled like prices.
+type LiquidationCandidate struct {
+	LoanAsset       string
+	CollateralAsset string
+	// LiquidationAmount is the loan asset amount to send, including the liquidation reserve.
+	LiquidationAmount *big.Int
+	// CollateralAmount is the minimal collateral asset amount to receive.
+	CollateralAmount *big.Int
+	LiquidationValue *big.Int
+	CollateralValue  *big.Int
+	GasCost          *big.Int
+	// Profit is CollateralValue - LiquidationValue - GasCost.
+	Profit *big.Int
+	// Limited reports whether LiquidationAmount is limited by the liquidator balance.
+	Limited bool
+}
+
+// CalculateLiquidationCandidates evaluates every loan and collateral pair of a liquidatable position
+// under the same caps as CalculateLiquidationData and ranks them by profit, the most profitable first.
+// It returns nil if the position is not liquidatable.
+func (s *Service) CalculateLiquidationCandidates(user UserBalancer, assets assetManager, prices priceProvider, options *LiquidationOptions) []*LiquidationCandidate {
+	if options == nil {
+		options = &LiquidationOptions{}
+	}
+	health := s.CalculateHealth(user, assets, prices)
+	if !health.IsLiquidatable() {
+		return nil
+	}
+
+	calculator := NewCalculator(assets, prices, s.config)
+	report := s.CalculatePositionReport(user, assets, prices)
+	var candidates []*LiquidationCandidate
+	for _, loan := range report.Assets {
+		if loan.Value.Sign() != -1 {
+			continue
+		}
+		var balance *big.Int
+		if options.Balances != nil {
+			if balance = options.Balances[loan.Asset]; balance == nil || balance.Sign() <= 0 {
+				continue
+			}
+		}
+
+		for _, collateral := range report.Assets {
+			if collateral.Value.Sign() != 1 {
+				continue
+			}
+			candidate := s.liquidatePair(health, calculator, assets,
+				loan.Asset, new(big.Int).Neg(loan.Value), collateral.Asset, collateral.Value)
+			if balance != nil && balance.Cmp(candidate.LiquidationAmount) == -1 {
+				s.limitLiquidation(candidate, calculator, assets, balance)
+			}
+			if candidate.LiquidationAmount.Sign() == 0 || candidate.CollateralAmount.Sign() == 0 {
+				continue
+			}
+
+			candidate.GasCost = new(big.Int)
+			if options.GasCost != nil {
+				candidate.GasCost = options.GasCost(loan.Asset, collateral.Asset)
+			}
+			candidate.Profit = new(big.Int).Sub(candidate.CollateralValue, candidate.LiquidationValue)
+			candidate.Profit.Sub(candidate.Profit, candidate.GasCost)
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if c := candidates[i].Profit.Cmp(candidates[j].Profit); c != 0 {
+			return c == 1
+		}
+		if candidates[i].LoanAsset != candidates[j].LoanAsset {
+			return candidates[i].LoanAsset < candidates[j].LoanAsset
+		}
+		return candidates[i].CollateralAsset < candidates[j].CollateralAsset
+	})
+	return candidates
+}
+
+// liquidatePair calculates the greatest liquidation of the pair the contract accepts:
+// only half of the collateral worth more than CollateralWorthThreshold can be taken unless the position is bad debt.
+func (s *Service) liquidatePair(health *Health, calculator *Calculator, assets assetManager, loanAsset string, loanValue *big.Int, collateralAsset string, collateralValue *big.Int) *LiquidationCandidate {
+	params := s.config.MasterParams
+	loanAssetConfig := assets.Config(loanAsset)
+	collateralAssetConfig := assets.Config(collateralAsset)
+
+	allowedCollateralValue := collateralValue
+	if !health.IsBadDebt(collateralAssetConfig.LiquidationBonus, params.AssetLiquidationBonusScale) {
+		allowedCollateralValue = bigIntMin(allowedCollateralValue, bigIntMax(
+			new(big.Int).Div(allowedCollateralValue, big.NewInt(2)), params.CollateralWorthThreshold))
+	}
+
+	liquidationValue := bigIntMin(loanValue, mulDiv(
+		allowedCollateralValue,
+		params.AssetLiquidationBonusScale,
+		collateralAssetConfig.LiquidationBonus),
+	)
+	collateralValue = mulDiv(liquidationValue, collateralAssetConfig.LiquidationBonus, params.AssetLiquidationBonusScale)
+
+	liquidationValue = mulDiv(
+		liquidationValue,
+		params.AssetLiquidationReserveFactorScale,
+		new(big.Int).Sub(params.AssetLiquidationReserveFactorScale, loanAssetConfig.LiquidationReserveFactor),
+	)
+
+	return &LiquidationCandidate{
+		LoanAsset:         loanAsset,
+		CollateralAsset:   collateralAsset,
+		LiquidationAmount: calculator.BalanceFromValue(liquidationValue, loanAsset),
+		CollateralAmount:  calculator.BalanceFromValue(collateralValue, collateralAsset),
+		LiquidationValue:  liquidationValue,
+		CollateralValue:   collateralValue,
+	}
+}
+
+// limitLiquidation reduces the liquidation to the amount the liquidator has.
+func (s *Service) limitLiquidation(candidate *LiquidationCandidate, calculator *Calculator, assets assetManager, amount *big.Int) {
+	params := s.config.MasterParams
+	liquidationValue := calculator.ValueFromBalance(amount, candidate.LoanAsset)
+	repaidValue := mulDiv(
+		liquidationValue,
+		new(big.Int).Sub(params.AssetLiquidationReserveFactorScale, assets.Config(candidate.LoanAsset).LiquidationReserveFactor),
+		params.AssetLiquidationReserveFactorScale,
+	)
+	collateralValue := mulDiv(repaidValue, assets.Config(candidate.CollateralAsset).LiquidationBonus, params.AssetLiquidationBonusScale)
+
+	candidate.LiquidationAmount = new(big.Int).Set(amount)
+	candidate.LiquidationValue = liquidationValue
+	candidate.CollateralValue = collateralValue
+	candidate.CollateralAmount = calculator.BalanceFromValue(collateralValue, candidate.CollateralAsset)
+	candidate.Limited = true
+}
@@ -0,0 +1,156 @@
+package principal
+
+import (
+	"math/big"
+	"sort"
+)
+
+// maxPriceDoublings bounds the search for a price at which the position state is different.
+const maxPriceDoublings = 256
+
+// LiquidationPrice is the oracle price of an asset at which the position becomes liquidatable.
+type LiquidationPrice struct {
+	Asset string
+	// Price is the current oracle price.
+	Price *big.Int
+	// LiquidationPrice is the boundary price, the position is liquidatable at it and beyond.
+	// It is nil if the price of the asset alone can not change whether the position is liquidatable.
+	LiquidationPrice *big.Int
+	// Falling reports whether the position is liquidated once the price falls to LiquidationPrice,
+	// as for a collateral, otherwise once it rises to it, as for a loan.
+	Falling bool
+}
+
+// JointLiquidationPrice is the boundary of a group of assets whose prices move together by the same ratio.
+type JointLiquidationPrice struct {
+	// Ratio is the multiplier of the current prices at which the position becomes liquidatable,
+	// nil if moving the group prices can not change whether the position is liquidatable.
+	Ratio   *big.Rat
+	Falling bool
+	Prices  []*LiquidationPrice
+}
+
+// CalculateLiquidationPrices returns the liquidation price of every asset the user has a position in,
+// holding the prices of other assets constant. The boundaries are exact, they are found by evaluating
+// Health.IsLiquidatable with the same integer arithmetic as CalculateHealth.
+func (s *Service) CalculateLiquidationPrices(user UserBalancer, assets assetManager, prices priceProvider) []*LiquidationPrice {
+	report := s.CalculatePositionReport(user, assets, prices)
+	liquidationPrices := make([]*LiquidationPrice, 0, len(report.Assets))
+	for _, position := range report.Assets {
+		price := prices.Get(position.Asset)
+		if price == nil || price.Sign() <= 0 {
+			continue
+		}
+		liquidationPrice := &LiquidationPrice{Asset: position.Asset, Price: price, Falling: position.Value.Sign() == 1}
+		// with the price itself as the scale, the searched multiplier is the price
+		if ratio := s.searchLiquidationRatio(user, assets, prices, []string{position.Asset}, price, liquidationPrice.Falling); ratio != nil {
+			liquidationPrice.LiquidationPrice = ratio
+		}
+		liquidationPrices = append(liquidationPrices, liquidationPrice)
+	}
+	return liquidationPrices
+}
+
+// CalculateJointLiquidationPrice solves for the ratio by which the prices of correlated assets,
+// e.g. TON, stTON and tsTON, have to move together for the position to become liquidatable.
+// The ratio is found with the precision of the price scale.
+func (s *Service) CalculateJointLiquidationPrice(user UserBalancer, assets assetManager, prices priceProvider, group ...string) *JointLiquidationPrice {
+	report := s.CalculatePositionReport(user, assets, prices)
+
+	// the group exposure is the weighted collateral minus the debt that move with the prices
+	exposure := new(big.Int)
+	for _, asset := range group {
+		if position := report.Asset(asset); position != nil {
+			if position.Value.Sign() == 1 {
+				exposure.Add(exposure, position.LiquidationLimit)
+			} else {
+				exposure.Add(exposure, position.Value)
+			}
+		}
+	}
+
+	joint := &JointLiquidationPrice{Falling: exposure.Sign() >= 0}
+	scale := s.config.MasterParams.AssetPriceScale
+	var ratio *big.Int
+	if exposure.Sign() != 0 {
+		ratio = s.searchLiquidationRatio(user, assets, prices, group, scale, joint.Falling)
+	}
+	if ratio != nil {
+		joint.Ratio = new(big.Rat).SetFrac(ratio, scale)
+	}
+
+	for _, asset := range group {
+		price := prices.Get(asset)
+		if price == nil {
+			continue
+		}
+		liquidationPrice := &LiquidationPrice{Asset: asset, Price: price, Falling: joint.Falling}
+		if ratio != nil {
+			liquidationPrice.LiquidationPrice = mulDiv(price, ratio, scale)
+		}
+		joint.Prices = append(joint.Prices, liquidationPrice)
+	}
+	sort.Slice(joint.Prices, func(i, j int) bool {
+		return joint.Prices[i].Asset < joint.Prices[j].Asset
+	})
+	return joint
+}
+
+// searchLiquidationRatio finds the multiplier k of the group prices, scaled by scale, at the liquidation boundary:
+// the greatest k at which the position is liquidatable if falling, otherwise the least one.
+func (s *Service) searchLiquidationRatio(user UserBalancer, assets assetManager, prices priceProvider, group []string, scale *big.Int, falling bool) *big.Int {
+	liquidatable := func(k *big.Int) bool {
+		scaled := &scaledPrices{prices: prices, group: make(map[string]struct{}, len(group)), k: k, scale: scale}
+		for _, asset := range group {
+			scaled.group[asset] = struct{}{}
+		}
+		return s.CalculateHealth(user, assets, scaled).IsLiquidatable()
+	}
+
+	zero := new(big.Int)
+	if liquidatable(zero) != falling {
+		// falling: safe even at zero prices, rising: liquidatable whatever the prices are
+		return nil
+	}
+
+	// find a multiplier on the other side of the boundary
+	lo, hi := zero, new(big.Int).Set(scale)
+	for i := 0; liquidatable(hi) == falling; i++ {
+		if i == maxPriceDoublings {
+			return nil
+		}
+		lo, hi = hi, new(big.Int).Lsh(hi, 1)
+	}
+
+	// liquidatable(lo) == falling and liquidatable(hi) != falling
+	one := big.NewInt(1)
+	for new(big.Int).Sub(hi, lo).Cmp(one) > 0 {
+		mid := new(big.Int).Add(lo, hi)
+		mid.Rsh(mid, 1)
+		if liquidatable(mid) == falling {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	if falling {
+		return lo
+	}
+	return hi
+}
+
+// scaledPrices multiplies the prices of the group assets by k / scale.
+type scaledPrices struct {
+	prices priceProvider
+	group  map[string]struct{}
+	k      *big.Int
+	scale  *big.Int
+}
+
+func (p *scaledPrices) Get(asset string) *big.Int {
+	price := p.prices.Get(asset)
+	if _, ok := p.group[asset]; !ok || price == nil {
+		return price
+	}
+	return mulDiv(price, p.k, p.scale)
+}
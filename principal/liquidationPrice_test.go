@@ -0,0 +1,91 @@
+package principal
+
+import (
+	"maps"
+	"math/big"
+	"testing"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+func TestService_CalculateLiquidationPrices(t *testing.T) {
+	cfg, parser, service := newFixture(t)
+	tonAsset, usdtAsset, stTONAsset := config.TON.ID(), config.USDT.ID(), config.STTON.ID()
+	prices := fixturePrices(5575000000)
+	prices[stTONAsset] = big.NewInt(5975000000)
+	prices[config.TSTON.ID()] = big.NewInt(5975000000)
+	user := NewUserSC(nil)
+	user.principals = map[string]*big.Int{
+		tonAsset:   big.NewInt(1350457583812),
+		stTONAsset: big.NewInt(100_000_000_000),
+		usdtAsset:  big.NewInt(-4519473935),
+	}
+	if service.CalculateHealth(user, parser, prices).IsLiquidatable() {
+		t.Fatalf("position should not be liquidatable at current prices")
+	}
+
+	with := func(asset string, price *big.Int) Prices {
+		p := maps.Clone(prices)
+		p[asset] = price
+		return p
+	}
+	one := big.NewInt(1)
+
+	liquidationPrices := service.CalculateLiquidationPrices(user, parser, prices)
+	if len(liquidationPrices) != 3 {
+		t.Fatalf("liquidation prices want %d, got %d", 3, len(liquidationPrices))
+	}
+	for _, lp := range liquidationPrices {
+		if lp.Asset == stTONAsset {
+			// TON alone covers the debt
+			if lp.LiquidationPrice != nil {
+				t.Errorf("stTON LiquidationPrice want nil, got %s", lp.LiquidationPrice)
+			}
+			continue
+		}
+		if lp.LiquidationPrice == nil {
+			t.Fatalf("LiquidationPrice of %s is nil", cfg.Assets[lp.Asset].Name)
+		}
+		next := new(big.Int).Add(lp.LiquidationPrice, one)
+		if !lp.Falling {
+			next.Sub(lp.LiquidationPrice, one)
+		}
+		if !service.CalculateHealth(user, parser, with(lp.Asset, lp.LiquidationPrice)).IsLiquidatable() {
+			t.Errorf("%s: position should be liquidatable at %s", cfg.Assets[lp.Asset].Name, lp.LiquidationPrice)
+		}
+		if service.CalculateHealth(user, parser, with(lp.Asset, next)).IsLiquidatable() {
+			t.Errorf("%s: position should not be liquidatable at %s", cfg.Assets[lp.Asset].Name, next)
+		}
+		if lp.Falling != (lp.Asset != usdtAsset) {
+			t.Errorf("%s: Falling want %t, got %t", cfg.Assets[lp.Asset].Name, lp.Asset != usdtAsset, lp.Falling)
+		}
+		if lp.Falling == (lp.LiquidationPrice.Cmp(lp.Price) > 0) {
+			t.Errorf("%s: LiquidationPrice %s is on the wrong side of %s", cfg.Assets[lp.Asset].Name, lp.LiquidationPrice, lp.Price)
+		}
+	}
+
+	joint := service.CalculateJointLiquidationPrice(user, parser, prices, tonAsset, stTONAsset, config.TSTON.ID())
+	if joint.Ratio == nil || !joint.Falling {
+		t.Fatalf("joint liquidation want a falling ratio, got %v, %t", joint.Ratio, joint.Falling)
+	}
+	if joint.Ratio.Cmp(big.NewRat(1, 1)) >= 0 {
+		t.Errorf("joint Ratio want below 1, got %s", joint.Ratio.FloatString(9))
+	}
+	jointPrices := maps.Clone(prices)
+	for _, lp := range joint.Prices {
+		jointPrices[lp.Asset] = lp.LiquidationPrice
+	}
+	if !service.CalculateHealth(user, parser, jointPrices).IsLiquidatable() {
+		t.Errorf("position should be liquidatable at the joint liquidation prices")
+	}
+	// the TON boundary alone is lower than when stTON falls along with it
+	for _, lp := range liquidationPrices {
+		if lp.Asset == tonAsset && lp.LiquidationPrice.Cmp(jointPrices[tonAsset]) >= 0 {
+			t.Errorf("TON liquidation price %s should be below the joint one %s", lp.LiquidationPrice, jointPrices[tonAsset])
+		}
+	}
+
+	if joint := service.CalculateJointLiquidationPrice(user, parser, prices, config.JUSDC.ID()); joint.Ratio != nil {
+		t.Errorf("assets without position want no joint ratio, got %s", joint.Ratio)
+	}
+}
@@ -0,0 +1,93 @@
+package principal
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+func TestService_CalculateLiquidationCandidates(t *testing.T) {
+	_, parser, service := newFixture(t)
+	tonAsset, usdtAsset, stTONAsset, jUSDTAsset := config.TON.ID(), config.USDT.ID(), config.STTON.ID(), config.JUSDT.ID()
+	prices := fixturePrices(4575000000)
+	user := NewUserSC(nil)
+	user.principals = map[string]*big.Int{
+		tonAsset:   big.NewInt(1350457583812),
+		stTONAsset: big.NewInt(20_000_000_000),
+		usdtAsset:  big.NewInt(-4519473935),
+		jUSDTAsset: big.NewInt(-100_000_000),
+	}
+
+	candidates := service.CalculateLiquidationCandidates(user, parser, prices, nil)
+	if len(candidates) != 4 {
+		t.Fatalf("candidates want %d, got %d", 4, len(candidates))
+	}
+	for i := 1; i < len(candidates); i++ {
+		if candidates[i-1].Profit.Cmp(candidates[i].Profit) == -1 {
+			t.Errorf("candidates are not ranked by profit")
+		}
+	}
+
+	_, liquidationAmount, collateralAmount, ok := service.CalculateLiquidationData(user, parser, prices)
+	if !ok {
+		t.Fatalf("position should be liquidatable")
+	}
+	for _, candidate := range candidates {
+		if candidate.LoanAsset == usdtAsset && candidate.CollateralAsset == tonAsset {
+			if candidate.LiquidationAmount.Cmp(liquidationAmount) != 0 || candidate.CollateralAmount.Cmp(collateralAmount) != 0 {
+				t.Errorf("greatest pair want %s/%s, got %s/%s", liquidationAmount, collateralAmount,
+					candidate.LiquidationAmount, candidate.CollateralAmount)
+			}
+		}
+		want := new(big.Int).Sub(candidate.CollateralValue, candidate.LiquidationValue)
+		if candidate.Profit.Cmp(want) != 0 || candidate.Limited {
+			t.Errorf("unexpected candidate %+v", candidate)
+		}
+	}
+
+	limit := big.NewInt(1_000_000)
+	candidates = service.CalculateLiquidationCandidates(user, parser, prices, &LiquidationOptions{
+		Balances: map[string]*big.Int{usdtAsset: limit},
+		GasCost: func(string, string) *big.Int {
+			return big.NewInt(100_000_000)
+		},
+	})
+	if len(candidates) != 2 {
+		t.Fatalf("candidates with USDT only want %d, got %d", 2, len(candidates))
+	}
+	for _, candidate := range candidates {
+		if candidate.LoanAsset != usdtAsset || !candidate.Limited || candidate.LiquidationAmount.Cmp(limit) != 0 {
+			t.Errorf("unexpected limited candidate %+v", candidate)
+		}
+		want := new(big.Int).Sub(candidate.CollateralValue, candidate.LiquidationValue)
+		if want.Sub(want, big.NewInt(100_000_000)); candidate.Profit.Cmp(want) != 0 {
+			t.Errorf("Profit want %s, got %s", want, candidate.Profit)
+		}
+	}
+
+	prices[tonAsset] = big.NewInt(6_000_000_000)
+	if candidates := service.CalculateLiquidationCandidates(user, parser, prices, nil); candidates != nil {
+		t.Errorf("healthy position want no candidates, got %d", len(candidates))
+	}
+}
+
+func TestService_CalculateLiquidationData_fixture(t *testing.T) {
+	_, parser, service := newFixture(t)
+	prices := fixturePrices(4575000000)
+	user := NewUserSC(nil)
+	user.principals = map[string]*big.Int{
+		config.TON.ID():  big.NewInt(1350457583812),
+		config.USDT.ID(): big.NewInt(-4519473935),
+	}
+	_, liquidationAmount, collateralAmount, ok := service.CalculateLiquidationData(user, parser, prices)
+	if !ok {
+		t.Fatalf("position should be liquidatable")
+	}
+	if liquidationAmount.Cmp(big.NewInt(2375549479)) != 0 {
+		t.Errorf("liquidationAmount want %d, got %s", 2375549479, liquidationAmount)
+	}
+	if collateralAmount.Cmp(big.NewInt(550008455532)) != 0 {
+		t.Errorf("collateralAmount want %d, got %s", 550008455532, collateralAmount)
+	}
+}
@@ -0,0 +1,172 @@
+package principal
+
+import (
+	"math/big"
+	"sort"
+)
+
+type HealthAction int
+
+const (
+	ActionRepay HealthAction = iota
+	ActionSupply
+	ActionWithdraw
+	ActionBorrow
+)
+
+func (a HealthAction) String() string {
+	switch a {
+	case ActionRepay:
+		return "repay"
+	case ActionSupply:
+		return "supply"
+	case ActionWithdraw:
+		return "withdraw"
+	case ActionBorrow:
+		return "borrow"
+	default:
+		return "unknown"
+	}
+}
+
+// HealthPlan is a single action which brings the position to the target health factor.
+type HealthPlan struct {
+	Action HealthAction
+	Asset  string
+	// AssetID and Amount are ready for transaction.SupplyParameters for ActionRepay and ActionSupply
+	// and for transaction.WithdrawParameters for ActionWithdraw and ActionBorrow.
+	AssetID *big.Int
+	Amount  *big.Int
+	// PrincipalChange is the change of the asset principal for UserBalancer.ChangePrincipal.
+	PrincipalChange *big.Int
+	// Health is the health of the position after the action.
+	Health *Health
+}
+
+// PlanHealth plans actions on a single asset each against the target health factor, see Health.ExactFactor.
+//
+// If the position is below the target, it returns the minimal amount to repay of every debt asset
+// and to supply of every collateral asset which is enough to reach the target on its own.
+// Otherwise it returns the maximal amount to withdraw of every supplied asset and to borrow of every asset
+// which keeps the position at or above the target and within the borrow limit.
+// Plans are ordered by action and asset.
+func (s *Service) PlanHealth(user UserBalancer, assets assetManager, prices priceProvider, target *big.Rat) []*HealthPlan {
+	calculator := NewCalculator(assets, prices, s.config)
+	var plans []*HealthPlan
+	plan := func(action HealthAction, asset string, amount *big.Int) {
+		delta := amount
+		if action == ActionWithdraw || action == ActionBorrow {
+			delta = new(big.Int).Neg(amount)
+		}
+		changed, principalChange := changeBalance(user, calculator, assets, asset, delta)
+		plans = append(plans, &HealthPlan{
+			Action:          action,
+			Asset:           asset,
+			AssetID:         assets.Assets()[asset],
+			Amount:          amount,
+			PrincipalChange: principalChange,
+			Health:          s.CalculateHealth(changed, assets, prices),
+		})
+	}
+	reachesWith := func(asset string, delta *big.Int) bool {
+		changed, _ := changeBalance(user, calculator, assets, asset, delta)
+		return reachesFactor(s.CalculateHealth(changed, assets, prices), target)
+	}
+
+	below := !reachesFactor(s.CalculateHealth(user, assets, prices), target)
+	for asset := range assets.Assets() {
+		balance := user.Balance(asset, assets.Data(asset), false, nil)
+		price := prices.Get(asset)
+		if price == nil || price.Sign() <= 0 {
+			continue
+		}
+
+		switch {
+		case below && balance.Sign() == -1:
+			debt := new(big.Int).Neg(balance)
+			if !reachesWith(asset, debt) {
+				continue
+			}
+			plan(ActionRepay, asset, searchAmount(debt, false, func(amount *big.Int) bool {
+				return reachesWith(asset, amount)
+			}))
+		case below:
+			if assets.Config(asset).LiquidationThreshold.Sign() == 0 {
+				continue
+			}
+			hi := new(big.Int).Set(assets.Config(asset).Scale())
+			for i := 0; !reachesWith(asset, hi); i++ {
+				if i == maxPriceDoublings {
+					hi = nil
+					break
+				}
+				hi.Lsh(hi, 1)
+			}
+			if hi != nil {
+				plan(ActionSupply, asset, searchAmount(hi, false, func(amount *big.Int) bool {
+					return reachesWith(asset, amount)
+				}))
+			}
+		default:
+			// CalculateMaximumWithdrawAmount keeps the position within the borrow limit
+			limit := s.CalculateMaximumWithdrawAmount(user, assets, prices, asset)
+			if limit.Sign() <= 0 {
+				continue
+			}
+			amount := searchAmount(limit, true, func(amount *big.Int) bool {
+				return reachesWith(asset, new(big.Int).Neg(amount))
+			})
+			if amount.Sign() == 0 {
+				continue
+			}
+			if balance.Sign() == 1 {
+				plan(ActionWithdraw, asset, amount)
+			} else {
+				plan(ActionBorrow, asset, amount)
+			}
+		}
+	}
+
+	sort.Slice(plans, func(i, j int) bool {
+		if plans[i].Action != plans[j].Action {
+			return plans[i].Action < plans[j].Action
+		}
+		return plans[i].Asset < plans[j].Asset
+	})
+	return plans
+}
+
+// searchAmount finds within [0, hi] the greatest amount satisfying ok if greatest,
+// otherwise the least one, given ok is monotonic and holds at 0 or hi respectively.
+func searchAmount(hi *big.Int, greatest bool, ok func(amount *big.Int) bool) *big.Int {
+	lo, hi := new(big.Int), new(big.Int).Set(hi)
+	if greatest && ok(hi) {
+		return hi
+	}
+	if !greatest && ok(lo) {
+		return lo
+	}
+
+	one := big.NewInt(1)
+	for new(big.Int).Sub(hi, lo).Cmp(one) > 0 {
+		mid := new(big.Int).Add(lo, hi)
+		mid.Rsh(mid, 1)
+		if ok(mid) == greatest {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	if greatest {
+		return lo
+	}
+	return hi
+}
+
+// changeBalance changes the asset balance of the user by delta and returns the matching principal change.
+func changeBalance(user UserBalancer, calculator *Calculator, assets assetManager, asset string, delta *big.Int) (UserBalancer, *big.Int) {
+	balance := user.Balance(asset, assets.Data(asset), false, nil)
+	principal := calculator.PrincipalFromBalance(new(big.Int).Add(balance, delta), asset)
+	principalChange := principal.Sub(principal, user.Principal(asset))
+	return user.ChangePrincipal(asset, principalChange), principalChange
+}
@@ -0,0 +1,73 @@
+package principal
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+func TestService_PlanHealth(t *testing.T) {
+	cfg, parser, service := newFixture(t)
+	tonAsset, usdtAsset := config.TON.ID(), config.USDT.ID()
+	prices := fixturePrices(4575000000)
+	user := NewUserSC(nil)
+	user.principals = map[string]*big.Int{
+		tonAsset:  big.NewInt(1350457583812),
+		usdtAsset: big.NewInt(-4519473935),
+	}
+	target := big.NewRat(1, 10)
+	reaches := func(user UserBalancer) bool {
+		factor := service.CalculateHealth(user, parser, prices).ExactFactor()
+		return factor != nil && factor.Cmp(target) >= 0
+	}
+
+	plans := service.PlanHealth(user, parser, prices, target)
+	if len(plans) != 6 {
+		t.Fatalf("plans want %d, got %d", 6, len(plans))
+	}
+	if plans[0].Action != ActionRepay || plans[0].Asset != usdtAsset {
+		t.Errorf("first plan want USDT repay, got %s of %s", plans[0].Action, cfg.Assets[plans[0].Asset].Name)
+	}
+	for _, plan := range plans[1:] {
+		if plan.Action != ActionSupply {
+			t.Errorf("plan want %s, got %s", ActionSupply, plan.Action)
+		}
+	}
+	for _, plan := range plans {
+		name := cfg.Assets[plan.Asset].Name
+		if plan.AssetID.String() != plan.Asset {
+			t.Errorf("%s %s: AssetID want %s, got %s", plan.Action, name, plan.Asset, plan.AssetID)
+		}
+		if !reaches(user.ChangePrincipal(plan.Asset, plan.PrincipalChange)) {
+			t.Errorf("%s %s: target is not reached with principal change %s", plan.Action, name, plan.PrincipalChange)
+		}
+		if factor := plan.Health.ExactFactor(); factor == nil || factor.Cmp(target) < 0 {
+			t.Errorf("%s %s: Health factor %v is below the target", plan.Action, name, factor)
+		}
+		smaller, _ := changeBalance(user, NewCalculator(parser, prices, cfg), parser, plan.Asset, new(big.Int).Sub(plan.Amount, big.NewInt(1)))
+		if reaches(smaller) {
+			t.Errorf("%s %s: amount %s is not minimal", plan.Action, name, plan.Amount)
+		}
+	}
+
+	safe := user.ChangePrincipal(plans[0].Asset, plans[0].PrincipalChange)
+	plans = service.PlanHealth(safe, parser, prices, big.NewRat(1, 20))
+	var withdraw *HealthPlan
+	for _, plan := range plans {
+		if plan.Action == ActionWithdraw {
+			withdraw = plan
+		} else if plan.Action != ActionBorrow {
+			t.Errorf("plan of a safe position want withdraw or borrow, got %s", plan.Action)
+		}
+		if factor := plan.Health.ExactFactor(); factor == nil || factor.Cmp(big.NewRat(1, 20)) < 0 {
+			t.Errorf("%s %s: Health factor %v is below the target", plan.Action, cfg.Assets[plan.Asset].Name, factor)
+		}
+	}
+	if withdraw == nil || withdraw.Asset != tonAsset || withdraw.PrincipalChange.Sign() != -1 {
+		t.Fatalf("want TON withdraw plan, got %+v", withdraw)
+	}
+	if withdraw.Amount.Cmp(service.CalculateMaximumWithdrawAmount(safe, parser, prices, tonAsset)) > 0 {
+		t.Errorf("withdraw amount %s exceeds the borrow limit", withdraw.Amount)
+	}
+}
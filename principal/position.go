@@ -0,0 +1,117 @@
+package principal
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+// AssetPosition is a single asset row of a PositionReport, values are scaled like prices.
+type AssetPosition struct {
+	Asset string
+	Name  config.Asset
+	// Principal is the stored principal, negative for a loan.
+	Principal *big.Int
+	// Balance is the principal with the accrued interest, negative for a loan.
+	Balance *big.Int
+	// Value is the USD value of Balance, negative for a loan.
+	Value *big.Int
+	// BorrowLimit is the part of Value counted towards the borrow limit, zero for a loan.
+	BorrowLimit *big.Int
+	// LiquidationLimit is the part of Value counted towards the liquidation limit, zero for a loan.
+	LiquidationLimit *big.Int
+	// Dust reports whether a supply is below the asset dust, which can not be withdrawn on its own.
+	Dust bool
+	// Share is the part of TotalSupply for a supply or of TotalDebt for a loan.
+	Share *big.Rat
+}
+
+// PositionReport breaks the user position down per asset.
+type PositionReport struct {
+	// Assets are ordered by absolute value, greatest first.
+	Assets            []*AssetPosition
+	TotalSupply       *big.Int
+	TotalDebt         *big.Int
+	BorrowLimit       *big.Int
+	LiquidationLimit  *big.Int
+	AvailableToBorrow *big.Int
+}
+
+// Asset returns the row of the asset, nil if the user has no position in it.
+func (r *PositionReport) Asset(asset string) *AssetPosition {
+	for _, position := range r.Assets {
+		if position.Asset == asset {
+			return position
+		}
+	}
+	return nil
+}
+
+// CalculatePositionReport values every non-zero asset position of the user the same way CalculateHealth
+// and GetAvailableToBorrow do, so the report totals match their results.
+func (s *Service) CalculatePositionReport(user UserBalancer, assets assetManager, prices priceProvider) *PositionReport {
+	calculator := NewCalculator(assets, prices, s.config)
+	report := &PositionReport{
+		TotalSupply:      new(big.Int),
+		TotalDebt:        new(big.Int),
+		BorrowLimit:      new(big.Int),
+		LiquidationLimit: new(big.Int),
+	}
+
+	for assetID := range assets.Assets() {
+		balance := user.Balance(assetID, assets.Data(assetID), false, nil)
+		if balance.Sign() == 0 {
+			continue
+		}
+
+		assetConfig := assets.Config(assetID)
+		position := &AssetPosition{
+			Asset:            assetID,
+			Principal:        user.Principal(assetID),
+			Balance:          balance,
+			BorrowLimit:      new(big.Int),
+			LiquidationLimit: new(big.Int),
+		}
+		if cfg, ok := s.config.Assets[assetID]; ok {
+			position.Name = cfg.Name
+		}
+
+		if balance.Sign() == 1 {
+			position.Value = calculator.ValueFromBalance(balance, assetID)
+			position.BorrowLimit = mulDiv(position.Value, assetConfig.CollateralFactor, s.config.MasterParams.AssetCoefficientScale)
+			position.LiquidationLimit = mulDiv(position.Value, assetConfig.LiquidationThreshold, s.config.MasterParams.AssetLiquidationThresholdScale)
+			position.Dust = position.Principal.Cmp(assetConfig.Dust) == -1
+
+			report.TotalSupply.Add(report.TotalSupply, position.Value)
+			report.BorrowLimit.Add(report.BorrowLimit, position.BorrowLimit)
+			report.LiquidationLimit.Add(report.LiquidationLimit, position.LiquidationLimit)
+		} else {
+			value := calculator.ValueFromBalance(new(big.Int).Neg(balance), assetID)
+			position.Value = value.Neg(value)
+
+			report.TotalDebt.Sub(report.TotalDebt, position.Value)
+		}
+		report.Assets = append(report.Assets, position)
+	}
+	report.AvailableToBorrow = new(big.Int).Sub(report.BorrowLimit, report.TotalDebt)
+
+	for _, position := range report.Assets {
+		total := report.TotalSupply
+		if position.Value.Sign() == -1 {
+			total = report.TotalDebt
+		}
+		position.Share = new(big.Rat)
+		if total.Sign() != 0 {
+			position.Share.SetFrac(new(big.Int).Abs(position.Value), total)
+		}
+	}
+
+	sort.Slice(report.Assets, func(i, j int) bool {
+		if c := new(big.Int).Abs(report.Assets[i].Value).Cmp(new(big.Int).Abs(report.Assets[j].Value)); c != 0 {
+			return c == 1
+		}
+		return report.Assets[i].Asset < report.Assets[j].Asset
+	})
+	return report
+}
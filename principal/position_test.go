@@ -0,0 +1,102 @@
+package principal
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/evaafi/evaa-go-sdk/asset"
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+// newFixtureConfig returns the mainnet config limited to the assets of getAssetsService.
+func newFixtureConfig() *config.Config {
+	cfg := config.GetMainMainnetConfig()
+	assets := make(map[string]*config.AssetConfig)
+	for _, name := range []config.Asset{config.TON, config.USDT, config.JUSDT, config.JUSDC, config.STTON, config.TSTON} {
+		assets[name.ID()] = cfg.Assets[name.ID()]
+	}
+	cfg.Assets = assets
+	return cfg
+}
+
+// newFixture returns the fixture config, the asset data of getAssetsService and a service for them.
+func newFixture(t *testing.T) (*config.Config, *asset.Parser, *Service) {
+	cfg := newFixtureConfig()
+	return cfg, getAssetsService(t, cfg), NewService(cfg)
+}
+
+// fixturePrices returns prices of the fixture assets with the given TON price,
+// the other prices can be changed in place.
+func fixturePrices(tonPrice int64) Prices {
+	return Prices{
+		config.TON.ID():   big.NewInt(tonPrice),
+		config.USDT.ID():  big.NewInt(998760000),
+		config.JUSDT.ID(): big.NewInt(998760000),
+		config.JUSDC.ID(): big.NewInt(999908900),
+		config.STTON.ID(): big.NewInt(5030456829),
+		config.TSTON.ID(): big.NewInt(5003470805),
+	}
+}
+
+func TestService_CalculatePositionReport(t *testing.T) {
+	_, parser, service := newFixture(t)
+	tonAsset, usdtAsset, stTONAsset := config.TON.ID(), config.USDT.ID(), config.STTON.ID()
+	prices := fixturePrices(4575000000)
+	prices[stTONAsset] = big.NewInt(4975000000)
+	prices[config.TSTON.ID()] = big.NewInt(4975000000)
+	user := NewUserSC(nil)
+	user.principals = map[string]*big.Int{
+		tonAsset:   big.NewInt(1350457583812),
+		stTONAsset: big.NewInt(1_000_000),
+		usdtAsset:  big.NewInt(-4519473935),
+	}
+
+	report := service.CalculatePositionReport(user, parser, prices)
+	if len(report.Assets) != 3 {
+		t.Fatalf("assets want %d, got %d", 3, len(report.Assets))
+	}
+	if report.Assets[0].Asset != tonAsset || report.Assets[1].Asset != usdtAsset {
+		t.Errorf("assets should be ordered by value, got %s, %s", report.Assets[0].Name, report.Assets[1].Name)
+	}
+
+	health := service.CalculateHealth(user, parser, prices)
+	if report.TotalSupply.Cmp(health.TotalSupply) != 0 || report.TotalDebt.Cmp(health.TotalDebt) != 0 || report.LiquidationLimit.Cmp(health.TotalLimit) != 0 {
+		t.Errorf("totals want %s/%s/%s, got %s/%s/%s", health.TotalSupply, health.TotalDebt, health.TotalLimit,
+			report.TotalSupply, report.TotalDebt, report.LiquidationLimit)
+	}
+	if want := service.GetAvailableToBorrow(user, parser, prices); report.AvailableToBorrow.Cmp(want) != 0 {
+		t.Errorf("AvailableToBorrow want %s, got %s", want, report.AvailableToBorrow)
+	}
+
+	ton := report.Asset(tonAsset)
+	if ton.Name != config.TON || ton.Principal.Cmp(big.NewInt(1350457583812)) != 0 || ton.Dust {
+		t.Errorf("unexpected TON position %+v", ton)
+	}
+	if ton.BorrowLimit.Sign() != 1 || ton.BorrowLimit.Cmp(ton.LiquidationLimit) != -1 {
+		t.Errorf("TON borrow limit %s should be positive and below the liquidation limit %s", ton.BorrowLimit, ton.LiquidationLimit)
+	}
+	if !report.Asset(stTONAsset).Dust {
+		t.Errorf("stTON position should be dust")
+	}
+
+	usdt := report.Asset(usdtAsset)
+	if usdt.Value.Sign() != -1 || usdt.Balance.Sign() != -1 || usdt.BorrowLimit.Sign() != 0 {
+		t.Errorf("unexpected USDT position %+v", usdt)
+	}
+	if usdt.Share.Cmp(big.NewRat(1, 1)) != 0 {
+		t.Errorf("USDT Share want 1, got %s", usdt.Share)
+	}
+
+	supplyShare := new(big.Rat)
+	for _, position := range report.Assets {
+		if position.Value.Sign() == 1 {
+			supplyShare.Add(supplyShare, position.Share)
+		}
+	}
+	if supplyShare.Cmp(big.NewRat(1, 1)) != 0 {
+		t.Errorf("supply shares want 1 in total, got %s", supplyShare)
+	}
+	if report.Asset(config.JUSDC.ID()) != nil {
+		t.Errorf("jUSDC position want nil")
+	}
+}
@@ -0,0 +1,193 @@
+package principal
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/evaafi/evaa-go-sdk/asset"
+)
+
+const (
+	// DefaultProjectionStep is the default ProjectionParameters.Step.
+	DefaultProjectionStep = 24 * time.Hour
+	// MaxLiquidationLookahead bounds the search for the moment a position becomes liquidatable.
+	MaxLiquidationLookahead = 10 * 365 * 24 * time.Hour
+	// maxLiquidationSteps bounds the number of steps of the liquidation search, a finer Step is coarsened to fit it.
+	maxLiquidationSteps = 10_000
+)
+
+type ProjectionParameters struct {
+	// Start is the moment of the first point, zero means now.
+	Start   time.Time
+	Horizon time.Duration
+	// Step is the interval between points and between interest accruals, zero means DefaultProjectionStep.
+	Step time.Duration
+	// HoldUtilization keeps the interest at its current level, otherwise it is recalculated on every step
+	// as the growing debt raises the utilization.
+	HoldUtilization bool
+}
+
+// ProjectionPoint is the position at a moment, values are scaled like prices.
+type ProjectionPoint struct {
+	At       time.Time
+	Balances map[string]*big.Int
+	Supply   *big.Int
+	Debt     *big.Int
+	NetWorth *big.Int
+	Health   *Health
+}
+
+type Projection struct {
+	Points []*ProjectionPoint
+	// LiquidationAt is the estimated moment the accrued interest makes the position liquidatable at the current prices,
+	// it is zero if that does not happen within MaxLiquidationLookahead.
+	LiquidationAt time.Time
+}
+
+// TimeToLiquidation returns the time from Start until LiquidationAt, ok is false if it is not expected.
+func (p *Projection) TimeToLiquidation() (_ time.Duration, ok bool) {
+	if p.LiquidationAt.IsZero() || len(p.Points) == 0 {
+		return 0, false
+	}
+	return p.LiquidationAt.Sub(p.Points[0].At), true
+}
+
+// ProjectPosition accrues the asset rates forward and values the position at the current prices
+// every step over the horizon. The principals are kept, only the accrued interest changes the balances.
+func (s *Service) ProjectPosition(user UserBalancer, assets assetManager, prices priceProvider, params *ProjectionParameters) *Projection {
+	start, step := params.Start, params.Step
+	if start.IsZero() {
+		start = time.Now()
+	}
+	if step <= 0 {
+		step = DefaultProjectionStep
+	}
+
+	p := newProjector(user, assets, params.HoldUtilization, start.Unix())
+	projection := &Projection{}
+	point := func(at time.Time) {
+		report := s.CalculatePositionReport(user, p, prices)
+		balances := make(map[string]*big.Int, len(report.Assets))
+		for _, position := range report.Assets {
+			balances[position.Asset] = position.Balance
+		}
+		projection.Points = append(projection.Points, &ProjectionPoint{
+			At:       at,
+			Balances: balances,
+			Supply:   report.TotalSupply,
+			Debt:     report.TotalDebt,
+			NetWorth: new(big.Int).Sub(report.TotalSupply, report.TotalDebt),
+			Health:   s.CalculateHealth(user, p, prices),
+		})
+	}
+
+	point(start)
+	end := start.Add(params.Horizon)
+	for at := start.Add(step); !at.After(end); at = at.Add(step) {
+		p.accrue(at.Unix())
+		point(at)
+	}
+	if last := projection.Points[len(projection.Points)-1].At; last.Before(end) {
+		p.accrue(end.Unix())
+		point(end)
+	}
+
+	projection.LiquidationAt = s.projectLiquidation(user, assets, prices, params.HoldUtilization, start, step)
+	return projection
+}
+
+// projectLiquidation steps forward until the position is liquidatable and then bisects the last step to a second.
+// The step is coarsened so that the search takes at most maxLiquidationSteps steps.
+func (s *Service) projectLiquidation(user UserBalancer, assets assetManager, prices priceProvider, holdUtilization bool, start time.Time, step time.Duration) time.Time {
+	step = max(step, MaxLiquidationLookahead/maxLiquidationSteps)
+	p := newProjector(user, assets, holdUtilization, start.Unix())
+	if s.CalculateHealth(user, p, prices).IsLiquidatable() {
+		return start
+	}
+	if s.CalculateHealth(user, p, prices).TotalDebt.Sign() == 0 {
+		return time.Time{}
+	}
+
+	for at := start.Add(step); !at.After(start.Add(MaxLiquidationLookahead)); at = at.Add(step) {
+		previous := p.clone()
+		p.accrue(at.Unix())
+		if !s.CalculateHealth(user, p, prices).IsLiquidatable() {
+			continue
+		}
+
+		lo, hi := at.Add(-step).Unix(), at.Unix()
+		for hi-lo > 1 {
+			mid := lo + (hi-lo)/2
+			probe := previous.clone()
+			probe.accrue(mid)
+			if s.CalculateHealth(user, probe, prices).IsLiquidatable() {
+				hi = mid
+			} else {
+				lo = mid
+			}
+		}
+		return time.Unix(hi, 0)
+	}
+	return time.Time{}
+}
+
+// projector is an assetManager with the data of the user assets accrued to a moment.
+type projector struct {
+	assetManager
+	holdUtilization bool
+	data            map[string]*asset.Data
+	supplyInterest  map[string]*big.Int
+	borrowInterest  map[string]*big.Int
+}
+
+func newProjector(user UserBalancer, assets assetManager, holdUtilization bool, ts int64) *projector {
+	p := &projector{
+		assetManager:    assets,
+		holdUtilization: holdUtilization,
+		data:            make(map[string]*asset.Data),
+		supplyInterest:  make(map[string]*big.Int),
+		borrowInterest:  make(map[string]*big.Int),
+	}
+	for id := range assets.Assets() {
+		// other assets do not change the position, and their rates may grow without bound over a long horizon
+		if user.Principal(id).Sign() == 0 {
+			continue
+		}
+		data := assets.Data(id)
+		p.supplyInterest[id], p.borrowInterest[id] = asset.CalculateInterest(data, assets.Config(id))
+		p.data[id] = data.AccrueInterest(p.supplyInterest[id], p.borrowInterest[id], ts)
+	}
+	return p
+}
+
+func (p *projector) Data(asset string) *asset.Data {
+	if data, ok := p.data[asset]; ok {
+		return data
+	}
+	return p.assetManager.Data(asset)
+}
+
+func (p *projector) accrue(ts int64) {
+	for id, data := range p.data {
+		if !p.holdUtilization {
+			p.supplyInterest[id], p.borrowInterest[id] = asset.CalculateInterest(data, p.Config(id))
+		}
+		p.data[id] = data.AccrueInterest(p.supplyInterest[id], p.borrowInterest[id], ts)
+	}
+}
+
+func (p *projector) clone() *projector {
+	clone := &projector{
+		assetManager:    p.assetManager,
+		holdUtilization: p.holdUtilization,
+		data:            make(map[string]*asset.Data, len(p.data)),
+		supplyInterest:  make(map[string]*big.Int, len(p.supplyInterest)),
+		borrowInterest:  make(map[string]*big.Int, len(p.borrowInterest)),
+	}
+	for id := range p.data {
+		clone.data[id] = p.data[id]
+		clone.supplyInterest[id] = p.supplyInterest[id]
+		clone.borrowInterest[id] = p.borrowInterest[id]
+	}
+	return clone
+}
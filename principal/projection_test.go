@@ -0,0 +1,78 @@
+package principal
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+func TestService_ProjectPosition(t *testing.T) {
+	_, parser, service := newFixture(t)
+	tonAsset, usdtAsset := config.TON.ID(), config.USDT.ID()
+	prices := fixturePrices(4800000000)
+	prices[usdtAsset] = big.NewInt(1000000000)
+	user := NewUserSC(nil).SetPrincipals(map[string]*big.Int{
+		tonAsset:  big.NewInt(1350457583812),
+		usdtAsset: big.NewInt(-4519473935),
+	})
+	start := time.Unix(parser.Data(usdtAsset).LastAccrual.Int64(), 0)
+
+	projection := service.ProjectPosition(user, parser, prices, &ProjectionParameters{Start: start, Horizon: 30 * 24 * time.Hour})
+	if len(projection.Points) != 31 {
+		t.Fatalf("points want %d, got %d", 31, len(projection.Points))
+	}
+	first, last := projection.Points[0], projection.Points[30]
+	if !last.At.Equal(start.Add(30 * 24 * time.Hour)) {
+		t.Errorf("last point At want %s, got %s", start.Add(30*24*time.Hour), last.At)
+	}
+	if first.Debt.Cmp(service.CalculateHealth(user, parser, prices).TotalDebt) != 0 {
+		t.Errorf("first point should match the current position")
+	}
+	for i := 1; i < len(projection.Points); i++ {
+		previous, point := projection.Points[i-1], projection.Points[i]
+		if point.Debt.Cmp(previous.Debt) != 1 || point.Balances[usdtAsset].Cmp(previous.Balances[usdtAsset]) != -1 {
+			t.Fatalf("debt should grow every day")
+		}
+		if point.Supply.Cmp(previous.Supply) == -1 {
+			t.Fatalf("supply should not shrink")
+		}
+		if new(big.Int).Sub(point.Supply, point.Debt).Cmp(point.NetWorth) != 0 {
+			t.Fatalf("NetWorth want Supply - Debt")
+		}
+	}
+
+	liquidationIn, ok := projection.TimeToLiquidation()
+	if !ok {
+		t.Fatalf("position with growing debt should become liquidatable")
+	}
+	if liquidationIn <= 30*24*time.Hour && !last.Health.IsLiquidatable() || liquidationIn > 30*24*time.Hour && last.Health.IsLiquidatable() {
+		t.Errorf("liquidation in %s does not match the points", liquidationIn)
+	}
+
+	held := service.ProjectPosition(user, parser, prices, &ProjectionParameters{Start: start, Horizon: 30 * 24 * time.Hour, HoldUtilization: true})
+	if held.Points[30].Debt.Cmp(last.Debt) == 1 {
+		t.Errorf("debt with utilization held %s should not exceed %s", held.Points[30].Debt, last.Debt)
+	}
+
+	// the horizon is not a multiple of the step, the last point is still at the horizon
+	weekly := service.ProjectPosition(user, parser, prices, &ProjectionParameters{Start: start, Horizon: 30 * 24 * time.Hour, Step: 7 * 24 * time.Hour})
+	if len(weekly.Points) != 6 {
+		t.Fatalf("points want %d, got %d", 6, len(weekly.Points))
+	}
+	if got := weekly.Points[5].At; !got.Equal(start.Add(30 * 24 * time.Hour)) {
+		t.Errorf("last point At want %s, got %s", start.Add(30*24*time.Hour), got)
+	}
+
+	// a fine step does not make the liquidation search walk the whole lookahead at it
+	minutely := service.ProjectPosition(user, parser, prices, &ProjectionParameters{Start: start, Step: time.Minute})
+	if minutelyIn, ok := minutely.TimeToLiquidation(); !ok || (minutelyIn-liquidationIn).Abs() > 24*time.Hour {
+		t.Errorf("liquidation with a fine step in %s, want about %s", minutelyIn, liquidationIn)
+	}
+
+	safe := NewUserSC(nil).SetPrincipals(map[string]*big.Int{tonAsset: big.NewInt(1_000_000_000)})
+	if _, ok := service.ProjectPosition(safe, parser, prices, &ProjectionParameters{Start: start}).TimeToLiquidation(); ok {
+		t.Errorf("position without debt should not become liquidatable")
+	}
+}
@@ -0,0 +1,55 @@
+package principal
+
+import (
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/evaafi/evaa-go-sdk/asset"
+)
+
+var ErrNoDebt = errors.New("no debt in the asset")
+
+type rateCalculator interface {
+	CalculateRatesAt(asset string, ts int64) (*asset.Data, *big.Int, *big.Int)
+}
+
+// Repayment is the amount which closes a loan at a given moment.
+type Repayment struct {
+	Asset string
+	At    time.Time
+	// Amount is the asset amount which repays the whole debt at At.
+	Amount *big.Int
+	// Data are the asset rates accrued up to At.
+	Data *asset.Data
+
+	factorScale *big.Int
+}
+
+// Excess returns the part of amount left over once the debt is repaid, it becomes a supply.
+func (r *Repayment) Excess(amount *big.Int) *big.Int {
+	return bigIntMax(big.NewInt(0), new(big.Int).Sub(amount, r.Amount))
+}
+
+// ExcessPrincipal returns the supply principal the excess of amount becomes at At.
+func (r *Repayment) ExcessPrincipal(amount *big.Int) *big.Int {
+	return mulDiv(r.Excess(amount), r.factorScale, r.Data.SRate)
+}
+
+// CalculateRepayment calculates the amount to repay the whole debt in the asset at the moment at,
+// accruing the borrow rate from the last accrual the same way the master contract does.
+func (s *Service) CalculateRepayment(user UserBalancer, rates rateCalculator, asset string, at time.Time) (*Repayment, error) {
+	if user.Principal(asset).Sign() != -1 {
+		return nil, ErrNoDebt
+	}
+
+	data, _, _ := rates.CalculateRatesAt(asset, at.Unix())
+	balance := user.Balance(asset, data, false, nil)
+	return &Repayment{
+		Asset:       asset,
+		At:          at,
+		Amount:      balance.Neg(balance),
+		Data:        data,
+		factorScale: s.config.MasterParams.FactorScale,
+	}, nil
+}
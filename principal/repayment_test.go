@@ -0,0 +1,58 @@
+package principal
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+func TestService_CalculateRepayment(t *testing.T) {
+	_, parser, service := newFixture(t)
+	usdtAsset := config.USDT.ID()
+	user := NewUserSC(nil)
+	user.principals = map[string]*big.Int{
+		config.TON.ID(): big.NewInt(1350457583812),
+		usdtAsset:       big.NewInt(-4519473935),
+	}
+
+	lastAccrual := time.Unix(parser.Data(usdtAsset).LastAccrual.Int64(), 0)
+	atAccrual, err := service.CalculateRepayment(user, parser, usdtAsset, lastAccrual)
+	if err != nil {
+		t.Fatalf("failed to calculate repayment, err: %s", err)
+	}
+	balance := user.Balance(usdtAsset, parser.Data(usdtAsset), false, nil)
+	if atAccrual.Amount.Cmp(new(big.Int).Neg(balance)) != 0 {
+		t.Errorf("Amount at last accrual want %s, got %s", new(big.Int).Neg(balance), atAccrual.Amount)
+	}
+
+	later, err := service.CalculateRepayment(user, parser, usdtAsset, lastAccrual.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("failed to calculate repayment, err: %s", err)
+	}
+	if later.Amount.Cmp(atAccrual.Amount) != 1 {
+		t.Errorf("Amount a day later %s should exceed %s", later.Amount, atAccrual.Amount)
+	}
+	repaid := user.ChangePrincipal(usdtAsset, new(big.Int).Neg(user.Principal(usdtAsset)))
+	if repaid.Balance(usdtAsset, later.Data, false, nil).Sign() != 0 {
+		t.Errorf("loan should be closed")
+	}
+
+	amount := new(big.Int).Add(later.Amount, big.NewInt(1_000_000))
+	if excess := later.Excess(amount); excess.Cmp(big.NewInt(1_000_000)) != 0 {
+		t.Errorf("Excess want %d, got %s", 1_000_000, excess)
+	}
+	if excess := later.Excess(atAccrual.Amount); excess.Sign() != 0 {
+		t.Errorf("Excess of a short amount want 0, got %s", excess)
+	}
+	principal := later.ExcessPrincipal(amount)
+	if supply := NewUserSC(nil).SetPrincipals(map[string]*big.Int{usdtAsset: principal}).Balance(usdtAsset, later.Data, false, nil); supply.Cmp(big.NewInt(1_000_000)) > 0 || supply.Cmp(big.NewInt(999_998)) < 0 {
+		t.Errorf("excess supply want about %d, got %s", 1_000_000, supply)
+	}
+
+	if _, err := service.CalculateRepayment(user, parser, config.TON.ID(), lastAccrual); !errors.Is(err, ErrNoDebt) {
+		t.Errorf("error want %s, got %v", ErrNoDebt, err)
+	}
+}
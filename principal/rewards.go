@@ -0,0 +1,94 @@
+package principal
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// RewardState is the reward tracking of a user in an asset as it is stored in the user rewards dictionary.
+type RewardState struct {
+	// TrackingIndex is the asset tracking index of the user side, supply or borrow, at the last principal change.
+	TrackingIndex *big.Int
+	// Accrued is the amount of rewards accrued up to TrackingIndex.
+	Accrued *big.Int
+}
+
+// ParseRewards decodes the user rewards dictionary keyed by asset id.
+func ParseRewards(dict *cell.Dictionary) (map[string]*RewardState, error) {
+	rewards := make(map[string]*RewardState)
+	if dict == nil || dict.IsEmpty() {
+		return rewards, nil
+	}
+
+	kvs, err := dict.LoadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rewards, err: %w", err)
+	}
+	for _, kv := range kvs {
+		key, err := kv.Key.LoadBigUInt(256)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load rewards asset, err: %w", err)
+		}
+		trackingIndex, err := kv.Value.LoadBigUInt(64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tracking index of %s, err: %w", key, err)
+		}
+		accrued, err := kv.Value.LoadBigUInt(64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load accrued rewards of %s, err: %w", key, err)
+		}
+		rewards[key.String()] = &RewardState{TrackingIndex: trackingIndex, Accrued: accrued}
+	}
+	return rewards, nil
+}
+
+// RewardStates returns the decoded Rewards.
+func (u *UserSC) RewardStates() (map[string]*RewardState, error) {
+	return ParseRewards(u.rewards)
+}
+
+// EstimateRewards returns an estimate of the reward states of the user accrued up to at as of a principal change
+// at that moment: the asset tracking index of the principal side is accrued to at,
+// and the principal earns its difference to the user tracking index scaled by the asset scale.
+// An asset with a principal missing in rewards is left out, its tracking start is not known.
+// The stored index is compared with the index of the current principal side,
+// so the result is only exact if the principal has not changed side since the stored state was written.
+//
+// The formula is not verified against the contract tracking index logic, and no vector with rewards enabled
+// backs it, so Accrued of the result must not be taken as the amount the user can claim.
+func (s *Service) EstimateRewards(user UserBalancer, rewards map[string]*RewardState, assets assetManager, at time.Time) map[string]*RewardState {
+	accrued := make(map[string]*RewardState)
+	for asset := range assets.Assets() {
+		state, ok := rewards[asset]
+		principal := user.Principal(asset)
+		if principal.Sign() == 0 {
+			if ok {
+				accrued[asset] = &RewardState{TrackingIndex: new(big.Int).Set(state.TrackingIndex), Accrued: new(big.Int).Set(state.Accrued)}
+			}
+			continue
+		}
+
+		if !ok {
+			continue
+		}
+
+		assetConfig := assets.Config(asset)
+		supplyIndex, borrowIndex := assets.Data(asset).TrackingIndexesAt(assetConfig, at.Unix())
+		index := supplyIndex
+		if principal.Sign() == -1 {
+			index = borrowIndex
+		}
+
+		earned := new(big.Int).Sub(index, state.TrackingIndex)
+		if earned.Sign() == 1 {
+			earned = mulDiv(new(big.Int).Abs(principal), earned, assetConfig.Scale())
+		} else {
+			earned.SetInt64(0)
+		}
+		accrued[asset] = &RewardState{TrackingIndex: index, Accrued: earned.Add(earned, state.Accrued)}
+	}
+	return accrued
+}
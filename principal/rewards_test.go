@@ -0,0 +1,152 @@
+package principal
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+
+	"github.com/evaafi/evaa-go-sdk/asset"
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+type rewardAssets struct {
+	config *asset.Config
+	data   *asset.Data
+}
+
+func (a *rewardAssets) Assets() map[string]*big.Int {
+	return map[string]*big.Int{config.USDT.ID(): config.USDT.Sha256Hash()}
+}
+
+func (a *rewardAssets) Config(string) *asset.Config {
+	return a.config
+}
+
+func (a *rewardAssets) Data(string) *asset.Data {
+	return a.data
+}
+
+func TestParseRewards(t *testing.T) {
+	dict := cell.NewDict(256)
+	value := cell.BeginCell().MustStoreUInt(1500, 64).MustStoreUInt(42, 64).EndCell()
+	if err := dict.SetIntKey(config.USDT.Sha256Hash(), value); err != nil {
+		t.Fatalf("%s", err)
+	}
+	rewards, err := ParseRewards(dict)
+	if err != nil {
+		t.Fatalf("ParseRewards err: %s", err)
+	}
+	state, ok := rewards[config.USDT.ID()]
+	if len(rewards) != 1 || !ok {
+		t.Fatalf("rewards want %s only, got %v", config.USDT.ID(), rewards)
+	}
+	if state.TrackingIndex.Int64() != 1500 || state.Accrued.Int64() != 42 {
+		t.Errorf("state want 1500/42, got %s/%s", state.TrackingIndex, state.Accrued)
+	}
+
+	if rewards, err := ParseRewards(nil); err != nil || len(rewards) != 0 {
+		t.Errorf("ParseRewards(nil) want empty, got %v, err: %v", rewards, err)
+	}
+	truncated := cell.NewDict(256)
+	_ = truncated.SetIntKey(config.USDT.Sha256Hash(), cell.BeginCell().MustStoreUInt(1, 64).EndCell())
+	if _, err := ParseRewards(truncated); err == nil {
+		t.Errorf("ParseRewards of a truncated value should fail")
+	}
+}
+
+func TestService_EstimateRewards(t *testing.T) {
+	start := int64(1730106018)
+	assets := &rewardAssets{
+		config: &asset.Config{
+			Decimals:                big.NewInt(6),
+			MinPrincipalForRewards:  big.NewInt(1000),
+			BaseTrackingSupplySpeed: big.NewInt(1_000_000_000),
+			BaseTrackingBorrowSpeed: big.NewInt(2_000_000_000),
+		},
+		data: &asset.Data{
+			TotalSupply:         big.NewInt(1_000_000_000_000),
+			TotalBorrow:         big.NewInt(500_000_000_000),
+			LastAccrual:         big.NewInt(start),
+			TrackingSupplyIndex: big.NewInt(3_000_000),
+			TrackingBorrowIndex: big.NewInt(7_000_000),
+		},
+	}
+	service := NewService(config.GetMainMainnetConfig())
+	rewards := map[string]*RewardState{config.USDT.ID(): {TrackingIndex: big.NewInt(2_000_000), Accrued: big.NewInt(5)}}
+	at := time.Unix(start+1000, 0)
+
+	for _, tt := range []struct {
+		name      string
+		principal int64
+		rewards   map[string]*RewardState
+		index     int64
+		accrued   int64
+	}{
+		// supply index 3e6 + 1e9 * 1000 * 1e6 / 1e12 = 4e6, earned 1e9 * (4e6 - 2e6) / 1e6
+		{name: "supply", principal: 1_000_000_000, rewards: rewards, index: 4_000_000, accrued: 2_000_000_005},
+		// borrow index 7e6 + 2e9 * 1000 * 1e6 / 5e11 = 11e6
+		{name: "borrow", principal: -1_000_000_000, rewards: rewards, index: 11_000_000, accrued: 9_000_000_005},
+		{name: "no principal", principal: 0, rewards: rewards, index: 2_000_000, accrued: 5},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			user := NewUserSC(nil).SetPrincipals(map[string]*big.Int{config.USDT.ID(): big.NewInt(tt.principal)})
+			state := service.EstimateRewards(user, tt.rewards, assets, at)[config.USDT.ID()]
+			if state.TrackingIndex.Int64() != tt.index || state.Accrued.Int64() != tt.accrued {
+				t.Errorf("state want %d/%d, got %s/%s", tt.index, tt.accrued, state.TrackingIndex, state.Accrued)
+			}
+		})
+	}
+	untracked := NewUserSC(nil).SetPrincipals(map[string]*big.Int{config.USDT.ID(): big.NewInt(1_000_000_000)})
+	if state, ok := service.EstimateRewards(untracked, nil, assets, at)[config.USDT.ID()]; ok {
+		t.Errorf("untracked asset should be left out, got %s/%s", state.TrackingIndex, state.Accrued)
+	}
+	if rewards[config.USDT.ID()].Accrued.Int64() != 5 {
+		t.Errorf("EstimateRewards should not change the rewards")
+	}
+
+	disabled := *assets.config
+	disabled.MinPrincipalForRewards = big.NewInt(0)
+	user := NewUserSC(nil).SetPrincipals(map[string]*big.Int{config.USDT.ID(): big.NewInt(1_000_000_000)})
+	state := service.EstimateRewards(user, rewards, &rewardAssets{config: &disabled, data: assets.data}, at)[config.USDT.ID()]
+	// the asset index is not accrued, the user still earns up to the stored one
+	if state.Accrued.Int64() != 1_000_000_005 {
+		t.Errorf("disabled rewards Accrued want %d, got %s", 1_000_000_005, state.Accrued)
+	}
+}
+
+func TestService_EstimateRewards_mainnet(t *testing.T) {
+	cfg, parser, service := newFixture(t)
+	dataBoc, err := hex.DecodeString(testUserDataBoc)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	data, err := cell.FromBOC(dataBoc)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	user := NewUserSC(nil)
+	if _, err := user.SetAccData(data); err != nil {
+		t.Fatalf("SetAccData err: %s", err)
+	}
+	rewards, err := user.RewardStates()
+	if err != nil {
+		t.Fatalf("RewardStates err: %s", err)
+	}
+
+	// rewards were disabled in the main pool at the time of both snapshots: the asset tracking indexes are zero
+	// and do not grow, and the user contract keeps zero states for every principal
+	at := time.Unix(parser.Data(config.USDT.ID()).LastAccrual.Int64()+24*60*60, 0)
+	accrued := service.EstimateRewards(user, rewards, parser, at)
+	for asset := range user.Principals() {
+		if _, ok := cfg.Assets[asset]; !ok {
+			continue
+		}
+		state, ok := accrued[asset]
+		if !ok || state.TrackingIndex.Sign() != 0 || state.Accrued.Sign() != 0 {
+			t.Errorf("%s state want zero, got %v", cfg.Assets[asset].Name, state)
+		}
+	}
+}
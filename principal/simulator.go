@@ -0,0 +1,122 @@
+package principal
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	ErrNotLiquidatable     = errors.New("position is not liquidatable")
+	ErrInvalidLiquidation  = errors.New("invalid liquidation")
+	ErrLiquidationTooLarge = errors.New("liquidation exceeds allowed collateral")
+	ErrMinCollateralNotMet = errors.New("collateral is less than min collateral amount")
+)
+
+// LiquidationRequest is a liquidation as it is sent to the master contract.
+type LiquidationRequest struct {
+	LoanAsset         string
+	LiquidationAmount *big.Int
+	CollateralAsset   string
+	// MinCollateralAmount is the least collateral the liquidator accepts, the liquidation is refunded otherwise.
+	MinCollateralAmount *big.Int
+}
+
+// LiquidationOutcome is the result of a simulated liquidation.
+type LiquidationOutcome struct {
+	// RepaidAmount is the loan asset amount which repays the debt.
+	RepaidAmount *big.Int
+	// ReserveAmount is the loan asset amount kept by the protocol as the liquidation reserve.
+	ReserveAmount *big.Int
+	// RefundAmount is the loan asset amount exceeding the debt, it is returned to the liquidator.
+	RefundAmount *big.Int
+	// CollateralAmount is the collateral asset amount paid to the liquidator.
+	CollateralAmount *big.Int
+	// Principals are the user principals after the liquidation.
+	Principals map[string]*big.Int
+	User       UserBalancer
+	Health     *Health
+}
+
+// SimulateLiquidation applies the liquidation to the user with the SDK liquidation math:
+// the liquidation reserve is taken from the amount, the rest repays the debt up to its balance,
+// and the collateral is paid for the repaid value with the collateral liquidation bonus.
+// Unless the position is bad debt, the collateral is limited the same way as in CalculateLiquidationData.
+//
+// The result is an estimate, it is not verified against the master contract or real liquidations.
+// In particular a liquidation exceeding the limit is rejected with ErrLiquidationTooLarge as a whole
+// rather than capped, and the collateral principal is rounded down by PrincipalFromBalance,
+// the contract may do either differently.
+func (s *Service) SimulateLiquidation(user UserBalancer, assets assetManager, prices priceProvider, request *LiquidationRequest) (*LiquidationOutcome, error) {
+	if request.LoanAsset == request.CollateralAsset || request.LiquidationAmount == nil || request.LiquidationAmount.Sign() <= 0 {
+		return nil, ErrInvalidLiquidation
+	}
+	health := s.CalculateHealth(user, assets, prices)
+	if !health.IsLiquidatable() {
+		return nil, ErrNotLiquidatable
+	}
+
+	params := s.config.MasterParams
+	calculator := NewCalculator(assets, prices, s.config)
+	loanConfig, collateralConfig := assets.Config(request.LoanAsset), assets.Config(request.CollateralAsset)
+	if loanConfig == nil || collateralConfig == nil {
+		return nil, fmt.Errorf("%w: unknown asset", ErrInvalidLiquidation)
+	}
+
+	loanBalance := user.Balance(request.LoanAsset, assets.Data(request.LoanAsset), false, nil)
+	collateralBalance := user.Balance(request.CollateralAsset, assets.Data(request.CollateralAsset), false, nil)
+	if loanBalance.Sign() != -1 {
+		return nil, fmt.Errorf("%w: no debt in the loan asset", ErrInvalidLiquidation)
+	}
+	if collateralBalance.Sign() != 1 {
+		return nil, fmt.Errorf("%w: no collateral in the collateral asset", ErrInvalidLiquidation)
+	}
+	debt := new(big.Int).Neg(loanBalance)
+
+	reserveScale := params.AssetLiquidationReserveFactorScale
+	repaidScale := new(big.Int).Sub(reserveScale, loanConfig.LiquidationReserveFactor)
+	repaid := mulDiv(request.LiquidationAmount, repaidScale, reserveScale)
+	used := new(big.Int).Set(request.LiquidationAmount)
+	if repaid.Cmp(debt) == 1 {
+		repaid = debt
+		used = mulDiv(repaid, reserveScale, repaidScale)
+	}
+
+	collateralValue := mulDiv(calculator.ValueFromBalance(repaid, request.LoanAsset), collateralConfig.LiquidationBonus, params.AssetLiquidationBonusScale)
+	allowedCollateralValue := calculator.ValueFromBalance(collateralBalance, request.CollateralAsset)
+	if !health.IsBadDebt(collateralConfig.LiquidationBonus, params.AssetLiquidationBonusScale) {
+		allowedCollateralValue = bigIntMin(allowedCollateralValue, bigIntMax(
+			new(big.Int).Div(allowedCollateralValue, big.NewInt(2)), params.CollateralWorthThreshold))
+	}
+	if collateralValue.Cmp(allowedCollateralValue) == 1 {
+		return nil, fmt.Errorf("%w: collateral value %s, allowed %s", ErrLiquidationTooLarge, collateralValue, allowedCollateralValue)
+	}
+
+	collateralAmount := bigIntMin(calculator.BalanceFromValue(collateralValue, request.CollateralAsset), collateralBalance)
+	if request.MinCollateralAmount != nil && collateralAmount.Cmp(request.MinCollateralAmount) == -1 {
+		return nil, fmt.Errorf("%w: collateral %s, min %s", ErrMinCollateralNotMet, collateralAmount, request.MinCollateralAmount)
+	}
+
+	loanPrincipal := calculator.PrincipalFromBalance(new(big.Int).Add(loanBalance, repaid), request.LoanAsset)
+	collateralPrincipal := calculator.PrincipalFromBalance(new(big.Int).Sub(collateralBalance, collateralAmount), request.CollateralAsset)
+	liquidated := user.
+		ChangePrincipal(request.LoanAsset, new(big.Int).Sub(loanPrincipal, user.Principal(request.LoanAsset))).
+		ChangePrincipal(request.CollateralAsset, new(big.Int).Sub(collateralPrincipal, user.Principal(request.CollateralAsset)))
+
+	principals := make(map[string]*big.Int)
+	for asset := range assets.Assets() {
+		if principal := liquidated.Principal(asset); principal.Sign() != 0 {
+			principals[asset] = principal
+		}
+	}
+
+	return &LiquidationOutcome{
+		RepaidAmount:     repaid,
+		ReserveAmount:    new(big.Int).Sub(used, repaid),
+		RefundAmount:     new(big.Int).Sub(request.LiquidationAmount, used),
+		CollateralAmount: collateralAmount,
+		Principals:       principals,
+		User:             liquidated,
+		Health:           s.CalculateHealth(liquidated, assets, prices),
+	}, nil
+}
@@ -0,0 +1,101 @@
+package principal
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+func TestService_SimulateLiquidation(t *testing.T) {
+	_, parser, service := newFixture(t)
+	tonAsset, usdtAsset := config.TON.ID(), config.USDT.ID()
+	prices := fixturePrices(4575000000)
+	user := NewUserSC(nil)
+	user.principals = map[string]*big.Int{
+		tonAsset:  big.NewInt(1350457583812),
+		usdtAsset: big.NewInt(-4519473935),
+	}
+	_, liquidationAmount, minCollateralAmount, _ := service.CalculateLiquidationData(user, parser, prices)
+
+	outcome, err := service.SimulateLiquidation(user, parser, prices, &LiquidationRequest{
+		LoanAsset:           usdtAsset,
+		LiquidationAmount:   liquidationAmount,
+		CollateralAsset:     tonAsset,
+		MinCollateralAmount: mulDiv(minCollateralAmount, big.NewInt(999), big.NewInt(1000)),
+	})
+	if err != nil {
+		t.Fatalf("failed to simulate liquidation, err: %s", err)
+	}
+	// the expected values are the simulator output on the asset fixture, they guard against regressions
+	// but are not taken from liquidation transactions
+	for name, tt := range map[string]struct{ got, want *big.Int }{
+		"RepaidAmount":     {outcome.RepaidAmount, big.NewInt(2332789588)},
+		"ReserveAmount":    {outcome.ReserveAmount, big.NewInt(42759891)},
+		"RefundAmount":     {outcome.RefundAmount, big.NewInt(0)},
+		"CollateralAmount": {outcome.CollateralAmount, big.NewInt(550008455349)},
+		"TON principal":    {outcome.Principals[tonAsset], big.NewInt(675228792130)},
+		"USDT principal":   {outcome.Principals[usdtAsset], big.NewInt(-1837316884)},
+	} {
+		if tt.got == nil || tt.got.Cmp(tt.want) != 0 {
+			t.Errorf("%s want %s, got %v", name, tt.want, tt.got)
+		}
+	}
+	health := service.CalculateHealth(user, parser, prices)
+	if outcome.Health.TotalDebt.Cmp(health.TotalDebt) != -1 || outcome.Health.Buffer().Cmp(health.Buffer()) != 1 {
+		t.Errorf("liquidation should reduce the debt and improve the buffer")
+	}
+
+	// the debt is repaid in full and the rest of the amount is refunded, but half of the collateral is not enough for it
+	_, err = service.SimulateLiquidation(user, parser, prices, &LiquidationRequest{
+		LoanAsset:         usdtAsset,
+		LiquidationAmount: new(big.Int).Mul(liquidationAmount, big.NewInt(3)),
+		CollateralAsset:   tonAsset,
+	})
+	if !errors.Is(err, ErrLiquidationTooLarge) {
+		t.Errorf("error want %s, got %v", ErrLiquidationTooLarge, err)
+	}
+
+	small := big.NewInt(1_000_000)
+	outcome, err = service.SimulateLiquidation(user, parser, prices, &LiquidationRequest{
+		LoanAsset:         usdtAsset,
+		LiquidationAmount: small,
+		CollateralAsset:   tonAsset,
+	})
+	if err != nil {
+		t.Fatalf("failed to simulate liquidation, err: %s", err)
+	}
+	if sum := new(big.Int).Add(outcome.RepaidAmount, outcome.ReserveAmount); sum.Cmp(small) != 0 {
+		t.Errorf("repaid and reserve want %s in total, got %s", small, sum)
+	}
+
+	_, err = service.SimulateLiquidation(user, parser, prices, &LiquidationRequest{
+		LoanAsset:           usdtAsset,
+		LiquidationAmount:   small,
+		CollateralAsset:     tonAsset,
+		MinCollateralAmount: new(big.Int).Add(outcome.CollateralAmount, big.NewInt(1)),
+	})
+	if !errors.Is(err, ErrMinCollateralNotMet) {
+		t.Errorf("error want %s, got %v", ErrMinCollateralNotMet, err)
+	}
+
+	_, err = service.SimulateLiquidation(user, parser, prices, &LiquidationRequest{
+		LoanAsset:         tonAsset,
+		LiquidationAmount: small,
+		CollateralAsset:   usdtAsset,
+	})
+	if !errors.Is(err, ErrInvalidLiquidation) {
+		t.Errorf("error want %s, got %v", ErrInvalidLiquidation, err)
+	}
+
+	prices[tonAsset] = big.NewInt(6_000_000_000)
+	_, err = service.SimulateLiquidation(user, parser, prices, &LiquidationRequest{
+		LoanAsset:         usdtAsset,
+		LiquidationAmount: small,
+		CollateralAsset:   tonAsset,
+	})
+	if !errors.Is(err, ErrNotLiquidatable) {
+		t.Errorf("error want %s, got %v", ErrNotLiquidatable, err)
+	}
+}
@@ -0,0 +1,126 @@
+package principal
+
+import (
+	"math/big"
+
+	"github.com/evaafi/evaa-go-sdk/asset"
+)
+
+// RateShock changes the accrued rate indices of an asset in basis points,
+// e.g. Borrow of 100 makes every debt in the asset 1% larger.
+type RateShock struct {
+	Supply int64
+	Borrow int64
+}
+
+// Scenario is a set of shocks applied to the base prices and asset rates.
+type Scenario struct {
+	Name string
+	// PriceShocks change the asset prices in basis points, e.g. -4000 for TON -40%.
+	PriceShocks map[string]int64
+	RateShocks  map[string]RateShock
+}
+
+// ScenarioReport sums up the users under a scenario, values are scaled like prices.
+type ScenarioReport struct {
+	Scenario     *Scenario
+	Users        int
+	Liquidatable int
+	// BadDebtUsers is the number of users whose debt exceeds their whole collateral.
+	BadDebtUsers int
+	// BadDebt is the uncovered debt value per loan asset, the shortfall of every user is split
+	// between the loan assets in proportion to their value.
+	BadDebt      map[string]*big.Int
+	TotalBadDebt *big.Int
+	// CollateralAtRisk is the collateral value of liquidatable users per asset.
+	CollateralAtRisk      map[string]*big.Int
+	TotalCollateralAtRisk *big.Int
+}
+
+// StressTest evaluates the users under every scenario, a scenario without shocks reports the base state.
+func (s *Service) StressTest(users []UserBalancer, assets assetManager, prices priceProvider, scenarios []*Scenario) []*ScenarioReport {
+	reports := make([]*ScenarioReport, 0, len(scenarios))
+	for _, scenario := range scenarios {
+		shockedAssets := &shockedAssets{assetManager: assets, shocks: scenario.RateShocks}
+		shockedPrices := &shockedPrices{prices: prices, shocks: scenario.PriceShocks}
+
+		report := &ScenarioReport{
+			Scenario:              scenario,
+			Users:                 len(users),
+			BadDebt:               make(map[string]*big.Int),
+			TotalBadDebt:          new(big.Int),
+			CollateralAtRisk:      make(map[string]*big.Int),
+			TotalCollateralAtRisk: new(big.Int),
+		}
+		for _, user := range users {
+			position := s.CalculatePositionReport(user, shockedAssets, shockedPrices)
+			if position.LiquidationLimit.Cmp(position.TotalDebt) != -1 {
+				continue
+			}
+
+			report.Liquidatable++
+			report.TotalCollateralAtRisk.Add(report.TotalCollateralAtRisk, position.TotalSupply)
+			for _, p := range position.Assets {
+				if p.Value.Sign() == 1 {
+					addTo(report.CollateralAtRisk, p.Asset, p.Value)
+				}
+			}
+
+			shortfall := new(big.Int).Sub(position.TotalDebt, position.TotalSupply)
+			if shortfall.Sign() != 1 {
+				continue
+			}
+			report.BadDebtUsers++
+			report.TotalBadDebt.Add(report.TotalBadDebt, shortfall)
+			for _, p := range position.Assets {
+				if p.Value.Sign() == -1 {
+					addTo(report.BadDebt, p.Asset, mulDiv(shortfall, new(big.Int).Neg(p.Value), position.TotalDebt))
+				}
+			}
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+func addTo(values map[string]*big.Int, asset string, value *big.Int) {
+	if current, ok := values[asset]; ok {
+		current.Add(current, value)
+		return
+	}
+	values[asset] = new(big.Int).Set(value)
+}
+
+// shockBps changes value by bps basis points.
+func shockBps(value *big.Int, bps int64) *big.Int {
+	if value == nil || bps == 0 {
+		return value
+	}
+	return mulDiv(value, big.NewInt(max(10_000+bps, 0)), big.NewInt(10_000))
+}
+
+type shockedPrices struct {
+	prices priceProvider
+	shocks map[string]int64
+}
+
+func (p *shockedPrices) Get(asset string) *big.Int {
+	return shockBps(p.prices.Get(asset), p.shocks[asset])
+}
+
+type shockedAssets struct {
+	assetManager
+	shocks map[string]RateShock
+}
+
+func (a *shockedAssets) Data(asset string) *asset.Data {
+	data := a.assetManager.Data(asset)
+	shock, ok := a.shocks[asset]
+	if !ok || data == nil {
+		return data
+	}
+	shocked := *data
+	shocked.SRate = shockBps(data.SRate, shock.Supply)
+	shocked.BRate = shockBps(data.BRate, shock.Borrow)
+	return &shocked
+}
@@ -0,0 +1,84 @@
+package principal
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+func TestService_StressTest(t *testing.T) {
+	_, parser, service := newFixture(t)
+	tonAsset, usdtAsset := config.TON.ID(), config.USDT.ID()
+	prices := fixturePrices(6000000000)
+	prices[usdtAsset] = big.NewInt(1000000000)
+	prices[config.STTON.ID()] = big.NewInt(6030456829)
+	prices[config.TSTON.ID()] = big.NewInt(6003470805)
+	newUser := func(principals map[string]*big.Int) UserBalancer {
+		return NewUserSC(nil).SetPrincipals(principals)
+	}
+	users := []UserBalancer{
+		// leveraged TON long
+		newUser(map[string]*big.Int{tonAsset: big.NewInt(1350457583812), usdtAsset: big.NewInt(-4519473935)}),
+		// conservative TON long
+		newUser(map[string]*big.Int{tonAsset: big.NewInt(1350457583812), usdtAsset: big.NewInt(-1000000000)}),
+		// TON short against USDT
+		newUser(map[string]*big.Int{usdtAsset: big.NewInt(10_000_000_000), tonAsset: big.NewInt(-1_200_000_000_000)}),
+		newUser(map[string]*big.Int{tonAsset: big.NewInt(1_000_000_000)}),
+	}
+
+	reports := service.StressTest(users, parser, prices, []*Scenario{
+		{Name: "base"},
+		{Name: "TON -40%", PriceShocks: map[string]int64{tonAsset: -4000}},
+		{Name: "TON -90%", PriceShocks: map[string]int64{tonAsset: -9000}},
+		{Name: "TON +40%", PriceShocks: map[string]int64{tonAsset: 4000}},
+		{Name: "USDT debt +60%", RateShocks: map[string]RateShock{usdtAsset: {Borrow: 6000}}},
+	})
+	if len(reports) != 5 {
+		t.Fatalf("reports want %d, got %d", 5, len(reports))
+	}
+	for i, want := range []struct {
+		liquidatable, badDebtUsers int
+	}{
+		{0, 0},
+		{1, 0},
+		{2, 2},
+		{1, 0},
+		{1, 0},
+	} {
+		report := reports[i]
+		if report.Users != len(users) || report.Liquidatable != want.liquidatable || report.BadDebtUsers != want.badDebtUsers {
+			t.Errorf("%s: liquidatable and bad debt users want %d/%d, got %d/%d", report.Scenario.Name,
+				want.liquidatable, want.badDebtUsers, report.Liquidatable, report.BadDebtUsers)
+		}
+
+		sum := new(big.Int)
+		for _, value := range report.BadDebt {
+			sum.Add(sum, value)
+		}
+		if diff := new(big.Int).Sub(report.TotalBadDebt, sum); diff.Sign() < 0 || diff.Cmp(big.NewInt(int64(len(users)))) > 0 {
+			t.Errorf("%s: BadDebt per asset %s does not add up to %s", report.Scenario.Name, sum, report.TotalBadDebt)
+		}
+		sum.SetInt64(0)
+		for _, value := range report.CollateralAtRisk {
+			sum.Add(sum, value)
+		}
+		if sum.Cmp(report.TotalCollateralAtRisk) != 0 {
+			t.Errorf("%s: CollateralAtRisk per asset %s does not add up to %s", report.Scenario.Name, sum, report.TotalCollateralAtRisk)
+		}
+	}
+
+	crash := reports[2]
+	if crash.BadDebt[usdtAsset] == nil || crash.BadDebt[usdtAsset].Sign() != 1 {
+		t.Errorf("TON crash want USDT bad debt, got %v", crash.BadDebt[usdtAsset])
+	}
+	if crash.CollateralAtRisk[tonAsset] == nil {
+		t.Errorf("TON crash want TON collateral at risk")
+	}
+	if pump := reports[3]; pump.CollateralAtRisk[usdtAsset] == nil || pump.CollateralAtRisk[tonAsset] != nil {
+		t.Errorf("TON pump want only USDT collateral at risk, got %v", pump.CollateralAtRisk)
+	}
+	if base := service.CalculateHealth(users[0], parser, prices); base.IsLiquidatable() {
+		t.Errorf("scenarios should not change the base state")
+	}
+}
@@ -1,7 +1,10 @@
 package principal
 
 import (
+	"errors"
+	"fmt"
 	"maps"
+	"math"
 	"math/big"
 
 	"github.com/evaafi/evaa-go-sdk/asset"
@@ -10,6 +13,19 @@ import (
 	"github.com/xssnick/tonutils-go/tvm/cell"
 )
 
+var ErrUnknownUserLayout = errors.New("unknown user contract data layout")
+
+// UserState is the user state field of the user contract. Its values are not mapped from the contract source:
+// only zero is named, it is the state of every user in the test data, and IsLocked assumes that any other value
+// means an operation on the user is in flight.
+type UserState int64
+
+const UserStateFree UserState = 0
+
+func (s UserState) IsLocked() bool {
+	return s != UserStateFree
+}
+
 type UserSC struct {
 	address *address.Address
 
@@ -21,6 +37,11 @@ type UserSC struct {
 	rewards       *cell.Dictionary
 	backupCell1   *cell.Cell
 	backupCell2   *cell.Cell
+
+	legacy              bool
+	trackingSupplyIndex *big.Int
+	trackingBorrowIndex *big.Int
+	dutchAuctionStart   uint32
 }
 
 func NewUserSC(addr *address.Address) *UserSC {
@@ -63,6 +84,36 @@ func (u *UserSC) UserState() int64 {
 	return u.userState
 }
 
+func (u *UserSC) State() UserState {
+	return UserState(u.userState)
+}
+
+// IsLocked reports whether the user state is non-zero, see UserState for the assumption behind it.
+func (u *UserSC) IsLocked() bool {
+	return u.State().IsLocked()
+}
+
+// IsLegacy reports whether the user data has the legacy layout which stores the tracking indexes,
+// the dutch auction start and a backup cell instead of the rewards dictionary and two backup cells.
+// Only TrackingSupplyIndex, TrackingBorrowIndex, DutchAuctionStart and BackupCell1 are set for it.
+// The legacy layout follows the commented-out decoding this package used to have, it is not checked
+// against the data of a real pre-rewards user contract.
+func (u *UserSC) IsLegacy() bool {
+	return u.legacy
+}
+
+func (u *UserSC) TrackingSupplyIndex() *big.Int {
+	return u.trackingSupplyIndex
+}
+
+func (u *UserSC) TrackingBorrowIndex() *big.Int {
+	return u.trackingBorrowIndex
+}
+
+func (u *UserSC) DutchAuctionStart() uint32 {
+	return u.dutchAuctionStart
+}
+
 func (u *UserSC) CheckNotInDebtAtAll() bool {
 	for _, principal := range u.principals {
 		if principal.Sign() == -1 {
@@ -104,90 +155,240 @@ func (u *UserSC) ChangePrincipal(asset string, amount *big.Int) UserBalancer {
 	principals := maps.Clone(u.principals)
 	principals[asset] = new(big.Int).Add(u.Principal(asset), amount)
 	return &UserSC{
-		address:       u.address,
-		codeVersion:   u.codeVersion,
-		userAddress:   u.userAddress,
-		masterAddress: u.masterAddress,
-		principals:    principals,
-		userState:     u.userState,
+		address:             u.address,
+		codeVersion:         u.codeVersion,
+		userAddress:         u.userAddress,
+		masterAddress:       u.masterAddress,
+		principals:          principals,
+		userState:           u.userState,
+		rewards:             u.rewards,
+		backupCell1:         u.backupCell1,
+		backupCell2:         u.backupCell2,
+		legacy:              u.legacy,
+		trackingSupplyIndex: u.trackingSupplyIndex,
+		trackingBorrowIndex: u.trackingBorrowIndex,
+		dutchAuctionStart:   u.dutchAuctionStart,
 	}
 }
 
-func (u *UserSC) SetAccData(userData *cell.Cell) (UserBalancer, error) {
+func (u *UserSC) SetAccData(userData *cell.Cell) (_ UserBalancer, err error) {
 	userSlice := userData.BeginParse()
-	u.codeVersion = userSlice.MustLoadCoins()
-	u.masterAddress = userSlice.MustLoadAddr()
-	u.userAddress = userSlice.MustLoadAddr()
-	principalsDict := userSlice.MustLoadDict(256)
-	if !principalsDict.IsEmpty() {
-		kvs, err := principalsDict.LoadAll()
+	if u.codeVersion, err = loadCoins(userSlice); err != nil {
+		return nil, fmt.Errorf("%w: failed to load code version, err: %w", ErrUnknownUserLayout, err)
+	}
+	if u.masterAddress, err = userSlice.LoadAddr(); err != nil {
+		return nil, fmt.Errorf("%w: failed to load master address, err: %w", ErrUnknownUserLayout, err)
+	}
+	if u.userAddress, err = userSlice.LoadAddr(); err != nil {
+		return nil, fmt.Errorf("%w: failed to load user address, err: %w", ErrUnknownUserLayout, err)
+	}
+	principalsDict, err := userSlice.LoadDict(256)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to load principals, err: %w", ErrUnknownUserLayout, err)
+	}
+	if err := u.loadPrincipals(principalsDict); err != nil {
+		return nil, err
+	}
+	userState, err := userSlice.LoadBigInt(64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to load user state, err: %w", ErrUnknownUserLayout, err)
+	}
+	u.userState = userState.Int64()
+
+	if err := u.loadVersionedData(userSlice); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func loadCoins(slice *cell.Slice) (uint64, error) {
+	coins, err := slice.LoadBigCoins()
+	if err != nil {
+		return 0, err
+	}
+	if !coins.IsUint64() {
+		return 0, fmt.Errorf("value %s overflows uint64", coins)
+	}
+	return coins.Uint64(), nil
+}
+
+func (u *UserSC) loadPrincipals(principalsDict *cell.Dictionary) error {
+	if principalsDict == nil || principalsDict.IsEmpty() {
+		return nil
+	}
+	kvs, err := principalsDict.LoadAll()
+	if err != nil {
+		return fmt.Errorf("%w: failed to load principals, err: %w", ErrUnknownUserLayout, err)
+	}
+	for _, kv := range kvs {
+		asset, err := kv.Key.LoadBigUInt(256)
 		if err != nil {
-			return nil, err
+			return fmt.Errorf("%w: failed to load principal asset, err: %w", ErrUnknownUserLayout, err)
 		}
+		value, err := kv.Value.LoadBigInt(64)
+		if err != nil {
+			return fmt.Errorf("%w: failed to load principal of %s, err: %w", ErrUnknownUserLayout, asset, err)
+		}
+		u.principals[asset.String()] = value
+	}
+	return nil
+}
 
-		for _, kv := range kvs {
-			value, err := kv.Value.LoadBigInt(64)
-			if err != nil {
-				return nil, err
-			}
+// loadVersionedData loads the data following the user state. The layout is told apart by the data size:
+// the legacy fields take more than 32 bits, the rewards ones take 3. The code version does not select
+// the layout, which code versions use which layout is not known here.
+func (u *UserSC) loadVersionedData(userSlice *cell.Slice) error {
+	load := u.loadRewardsData
+	if userSlice.BitsLeft() > 32 {
+		load = u.loadLegacyData
+	}
+	if err := load(userSlice); err != nil {
+		return err
+	}
+	if userSlice.BitsLeft() != 0 || userSlice.RefsNum() != 0 {
+		return fmt.Errorf("%w: %d bits and %d refs left for code version %d", ErrUnknownUserLayout, userSlice.BitsLeft(), userSlice.RefsNum(), u.codeVersion)
+	}
+	return nil
+}
 
-			u.principals[kv.Key.MustLoadBigUInt(256).String()] = value
+func (u *UserSC) loadLegacyData(userSlice *cell.Slice) (err error) {
+	u.legacy = true
+	if u.trackingSupplyIndex, err = userSlice.LoadBigUInt(64); err != nil {
+		return fmt.Errorf("%w: failed to load tracking supply index, err: %w", ErrUnknownUserLayout, err)
+	}
+	if u.trackingBorrowIndex, err = userSlice.LoadBigUInt(64); err != nil {
+		return fmt.Errorf("%w: failed to load tracking borrow index, err: %w", ErrUnknownUserLayout, err)
+	}
+	dutchAuctionStart, err := userSlice.LoadUInt(32)
+	if err != nil {
+		return fmt.Errorf("%w: failed to load dutch auction start, err: %w", ErrUnknownUserLayout, err)
+	}
+	u.dutchAuctionStart = uint32(dutchAuctionStart)
+	backupCell, err := userSlice.LoadRef()
+	if err != nil {
+		return fmt.Errorf("%w: failed to load backup cell, err: %w", ErrUnknownUserLayout, err)
+	}
+	u.backupCell1, err = backupCell.ToCell()
+	return err
+}
+
+func (u *UserSC) loadRewardsData(userSlice *cell.Slice) (err error) {
+	if u.rewards, err = userSlice.LoadDict(256); err != nil {
+		return fmt.Errorf("%w: failed to load rewards, err: %w", ErrUnknownUserLayout, err)
+	}
+	for _, backupCell := range []**cell.Cell{&u.backupCell1, &u.backupCell2} {
+		slice, err := userSlice.LoadMaybeRef()
+		if err != nil {
+			return fmt.Errorf("%w: failed to load backup cell, err: %w", ErrUnknownUserLayout, err)
+		}
+		if slice != nil {
+			if *backupCell, err = slice.ToCell(); err != nil {
+				return err
+			}
 		}
 	}
+	return nil
+}
 
-	u.userState = userSlice.MustLoadInt(64)
+// SetData loads the result of the user get-method. The layout is told apart by the stack the same way
+// as in SetAccData: the legacy one returns the tracking indexes and the dutch auction start as integers
+// in place of the rewards dictionary, followed by a single backup cell.
+func (u *UserSC) SetData(userData *ton.ExecutionResult) (_ UserBalancer, err error) {
+	codeVersion, err := userData.Int(0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to load code version, err: %w", ErrUnknownUserLayout, err)
+	}
+	if !codeVersion.IsUint64() {
+		return nil, fmt.Errorf("%w: invalid code version %s", ErrUnknownUserLayout, codeVersion)
+	}
+	u.codeVersion = codeVersion.Uint64()
+	if u.masterAddress, err = loadResultAddr(userData, 1); err != nil {
+		return nil, fmt.Errorf("%w: failed to load master address, err: %w", ErrUnknownUserLayout, err)
+	}
+	if u.userAddress, err = loadResultAddr(userData, 2); err != nil {
+		return nil, fmt.Errorf("%w: failed to load user address, err: %w", ErrUnknownUserLayout, err)
+	}
+	principals, err := loadResultMaybeCell(userData, 3)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to load principals, err: %w", ErrUnknownUserLayout, err)
+	}
+	if principals != nil {
+		if err := u.loadPrincipals(principals.AsDict(256)); err != nil {
+			return nil, err
+		}
+	}
+	userState, err := userData.Int(4)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to load user state, err: %w", ErrUnknownUserLayout, err)
+	}
+	u.userState = userState.Int64()
 
-	// Deprecated?
-	//if (userData.bitsLeft > 32) {
-	//	trackingSupplyIndex = userData.loadUintBig(64);
-	//	trackingBorrowIndex = userData.loadUintBig(64);
-	//	dutchAuctionStart = userData.loadUint(32);
-	//	backupCell = loadMyRef(userSlice);
-	//} else {
-	u.rewards = userSlice.MustLoadDict(256)
-	if slice := userSlice.MustLoadMaybeRef(); slice != nil {
-		u.backupCell1 = slice.MustToCell()
+	load := u.loadRewardsResult
+	if _, err := userData.Int(5); err == nil {
+		load = u.loadLegacyResult
 	}
-	if slice := userSlice.MustLoadMaybeRef(); slice != nil {
-		u.backupCell2 = slice.MustToCell()
+	if err := load(userData); err != nil {
+		return nil, err
 	}
-	//}
 	return u, nil
 }
 
-func (u *UserSC) SetData(userData *ton.ExecutionResult) (UserBalancer, error) {
-	u.codeVersion = userData.MustInt(0).Uint64()
-	u.masterAddress = userData.MustSlice(1).MustLoadAddr()
-	u.userAddress = userData.MustSlice(2).MustLoadAddr()
-
-	if !userData.MustIsNil(3) {
-		if principalsDict := userData.MustCell(3).AsDict(256); !principalsDict.IsEmpty() {
-			kvs, err := principalsDict.LoadAll()
-			if err != nil {
-				return nil, err
-			}
-
-			for _, kv := range kvs {
-				value, err := kv.Value.LoadBigInt(64)
-				if err != nil {
-					return nil, err
-				}
+func (u *UserSC) loadLegacyResult(userData *ton.ExecutionResult) (err error) {
+	u.legacy = true
+	if u.trackingSupplyIndex, err = userData.Int(5); err != nil {
+		return fmt.Errorf("%w: failed to load tracking supply index, err: %w", ErrUnknownUserLayout, err)
+	}
+	if u.trackingBorrowIndex, err = userData.Int(6); err != nil {
+		return fmt.Errorf("%w: failed to load tracking borrow index, err: %w", ErrUnknownUserLayout, err)
+	}
+	dutchAuctionStart, err := userData.Int(7)
+	if err != nil {
+		return fmt.Errorf("%w: failed to load dutch auction start, err: %w", ErrUnknownUserLayout, err)
+	}
+	if !dutchAuctionStart.IsUint64() || dutchAuctionStart.Uint64() > math.MaxUint32 {
+		return fmt.Errorf("%w: invalid dutch auction start %s", ErrUnknownUserLayout, dutchAuctionStart)
+	}
+	u.dutchAuctionStart = uint32(dutchAuctionStart.Uint64())
+	if u.backupCell1, err = loadResultMaybeCell(userData, 8); err != nil {
+		return fmt.Errorf("%w: failed to load backup cell, err: %w", ErrUnknownUserLayout, err)
+	}
+	return nil
+}
 
-				u.principals[kv.Key.MustLoadBigUInt(256).String()] = value
-			}
+func (u *UserSC) loadRewardsResult(userData *ton.ExecutionResult) error {
+	rewards, err := loadResultMaybeCell(userData, 5)
+	if err != nil {
+		return fmt.Errorf("%w: failed to load rewards, err: %w", ErrUnknownUserLayout, err)
+	}
+	if rewards != nil {
+		u.rewards = rewards.AsDict(256)
+	}
+	for i, backupCell := range []**cell.Cell{&u.backupCell1, &u.backupCell2} {
+		if *backupCell, err = loadResultMaybeCell(userData, uint(6+i)); err != nil {
+			return fmt.Errorf("%w: failed to load backup cell, err: %w", ErrUnknownUserLayout, err)
 		}
 	}
+	return nil
+}
 
-	u.userState = userData.MustInt(4).Int64()
-	u.rewards = userData.MustCell(5).AsDict(256)
-	if !userData.MustIsNil(6) {
-		u.backupCell1 = userData.MustCell(6)
+func loadResultAddr(userData *ton.ExecutionResult, index uint) (*address.Address, error) {
+	slice, err := userData.Slice(index)
+	if err != nil {
+		return nil, err
 	}
-	if !userData.MustIsNil(7) {
-		u.backupCell2 = userData.MustCell(7)
+	return slice.LoadAddr()
+}
+
+// loadResultMaybeCell returns the cell at index, or nil if the value is null.
+func loadResultMaybeCell(userData *ton.ExecutionResult, index uint) (*cell.Cell, error) {
+	isNil, err := userData.IsNil(index)
+	if err != nil {
+		return nil, err
 	}
-	//}
-	return u, nil
+	if isNil {
+		return nil, nil
+	}
+	return userData.Cell(index)
 }
 
 func (u *UserSC) SetPrincipals(principals map[string]*big.Int) UserBalancer {
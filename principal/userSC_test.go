@@ -1,7 +1,9 @@
 package principal
 
 import (
+	"bytes"
 	"encoding/hex"
+	"errors"
 	"math/big"
 	"reflect"
 	"testing"
@@ -13,6 +15,9 @@ import (
 	"github.com/evaafi/evaa-go-sdk/config"
 )
 
+// testUserDataBoc is the data of a mainnet user contract of the main pool with code version 6.
+const testUserDataBoc = "b5ee9c7201020f010001e9000299106801795a8cd48ff4acaea0e52aca4a79a0e79449b0ad00893212184201b2b9013f3d001941e9e16573eb68fd8a8269bb81b52585c98f2e626ed8e86dfd0037622afce2e0000000000000001201020201200304020120090a02012005060053bfe548035e9fd81e9aaed777fc9d925f4857d5371e50039ffab907c5429649993c7ffffb739c3d3cdac002012007080052bf895668e908644f30322b997de8faaafc21f05aa52f8982f042dac1fe0b4d09d00001c3b91faab2470051bf748433fcbcc1ac75e54798fb9cdfd8d368b8d6ae3092f4c291cf8465590f7b14000cdb460a300f750051bf6627c5eaf750e15e689006a18f136130fa2b6874a62e57f9c529bc43cfae49ce000af9207f047f710201200b0c0063bfe548035e9fd81e9aaed777fc9d925f4857d5371e50039ffab907c5429649993c00000000000000000000000000000000400201200d0e0062bf895668e908644f30322b997de8faaafc21f05aa52f8982f042dac1fe0b4d09d0000000000000000000000000000000000061bf748433fcbcc1ac75e54798fb9cdfd8d368b8d6ae3092f4c291cf8465590f7b14000000000000000000000000000000010061bf6627c5eaf750e15e689006a18f136130fa2b6874a62e57f9c529bc43cfae49ce00000000000000000000000000000001"
+
 func TestCalculateUserSCAddress(t *testing.T) {
 	service := NewService(config.GetMainMainnetConfig())
 	userSCAddress, err := service.CalculateUserSCAddress(address.MustParseAddr("UQBlB6eFlc-to_YqCabuBtSWFyY8uYm7Y6G39ADdiKvzi389"))
@@ -28,7 +33,7 @@ func TestUserCS_SetAccData(t *testing.T) {
 	masterAddress := address.MustParseAddr("EQC8rUZqR_pWV1BylWUlPNBzyiTYVoBEmQkMIQDZXICfnuRr")
 	userAddress := address.MustParseAddr("UQBlB6eFlc-to_YqCabuBtSWFyY8uYm7Y6G39ADdiKvzi389")
 	//dataBoc, err := hex.DecodeString("b5ee9c7241020701000111000299106801795a8cd48ff4acaea0e52aca4a79a0e79449b0ad00893212184201b2b9013f3d0001aafb2a1e6a6ad3c6137e7af73ce8a8a63086c27f1edb9cbcebbeaaf70ccb6aa00000000000000012010202012003040063a0092acd1d210c89e60645732fbd1f555f843e0b54a5f1305e085b583fc169a13a000000000000000000000000000000001002012005060053bfe548035e9fd81e9aaed777fc9d925f4857d5371e50039ffab907c5429649993c7ffffffffffff629400052bfb313e2f57ba870af34480350c789b0987d15b43a53172bfce294de21e7d724e70000000000135cbd0052bf895668e908644f30322b997de8faaafc21f05aa52f8982f042dac1fe0b4d09d00000005d54c31d38bf59b66f")
-	dataBoc, err := hex.DecodeString("b5ee9c7201020f010001e9000299106801795a8cd48ff4acaea0e52aca4a79a0e79449b0ad00893212184201b2b9013f3d001941e9e16573eb68fd8a8269bb81b52585c98f2e626ed8e86dfd0037622afce2e0000000000000001201020201200304020120090a02012005060053bfe548035e9fd81e9aaed777fc9d925f4857d5371e50039ffab907c5429649993c7ffffb739c3d3cdac002012007080052bf895668e908644f30322b997de8faaafc21f05aa52f8982f042dac1fe0b4d09d00001c3b91faab2470051bf748433fcbcc1ac75e54798fb9cdfd8d368b8d6ae3092f4c291cf8465590f7b14000cdb460a300f750051bf6627c5eaf750e15e689006a18f136130fa2b6874a62e57f9c529bc43cfae49ce000af9207f047f710201200b0c0063bfe548035e9fd81e9aaed777fc9d925f4857d5371e50039ffab907c5429649993c00000000000000000000000000000000400201200d0e0062bf895668e908644f30322b997de8faaafc21f05aa52f8982f042dac1fe0b4d09d0000000000000000000000000000000000061bf748433fcbcc1ac75e54798fb9cdfd8d368b8d6ae3092f4c291cf8465590f7b14000000000000000000000000000000010061bf6627c5eaf750e15e689006a18f136130fa2b6874a62e57f9c529bc43cfae49ce00000000000000000000000000000001")
+	dataBoc, err := hex.DecodeString(testUserDataBoc)
 	if err != nil {
 		t.Fatalf("%s", err)
 	}
@@ -76,6 +81,15 @@ func TestUserCS_SetAccData(t *testing.T) {
 	if len(rewardsKV) != 4 {
 		t.Errorf("len(rewardsKV) want %d, got %d", 4, len(rewardsKV))
 	}
+	rewardStates, err := user.RewardStates()
+	if err != nil {
+		t.Fatalf("RewardStates(), err %s", err)
+	}
+	for asset := range principalMap {
+		if state, ok := rewardStates[asset]; !ok || state.TrackingIndex.Sign() != 0 || state.Accrued.Sign() != 0 {
+			t.Errorf("RewardStates()[%s] want zero state, got %v", asset, state)
+		}
+	}
 	//for _, kv := range rewardsKV {
 	//	t.Logf("%v - %v", kv.Value, kv.Key)
 	//}
@@ -154,3 +168,119 @@ func TestUserCS_SetData(t *testing.T) {
 	//	t.Logf("%v - %v", kv.Value, kv.Key)
 	//}
 }
+
+func TestUserCS_SetAccData_layouts(t *testing.T) {
+	masterAddress := address.MustParseAddr("EQC8rUZqR_pWV1BylWUlPNBzyiTYVoBEmQkMIQDZXICfnuRr")
+	userAddress := address.MustParseAddr("UQBlB6eFlc-to_YqCabuBtSWFyY8uYm7Y6G39ADdiKvzi389")
+	principals := cell.NewDict(256)
+	_ = principals.SetIntKey(config.TON.Sha256Hash(), cell.BeginCell().MustStoreInt(1_000_000_000, 64).EndCell())
+	header := func(codeVersion uint64, state int64) *cell.Builder {
+		return cell.BeginCell().
+			MustStoreCoins(codeVersion).
+			MustStoreAddr(masterAddress).
+			MustStoreAddr(userAddress).
+			MustStoreDict(principals).
+			MustStoreInt(state, 64)
+	}
+	backupCell := cell.BeginCell().MustStoreUInt(7, 8).EndCell()
+
+	// the legacy cell is built by hand, no data of a real pre-rewards user contract is available
+	legacy := NewUserSC(nil)
+	if _, err := legacy.SetAccData(header(4, 1).
+		MustStoreUInt(11, 64).
+		MustStoreUInt(22, 64).
+		MustStoreUInt(1730106018, 32).
+		MustStoreRef(backupCell).
+		EndCell()); err != nil {
+		t.Fatalf("legacy SetAccData err: %s", err)
+	}
+	if !legacy.IsLegacy() || legacy.TrackingSupplyIndex().Int64() != 11 || legacy.TrackingBorrowIndex().Int64() != 22 || legacy.DutchAuctionStart() != 1730106018 {
+		t.Errorf("legacy fields want 11/22/1730106018, got %v/%s/%s/%d", legacy.IsLegacy(), legacy.TrackingSupplyIndex(), legacy.TrackingBorrowIndex(), legacy.DutchAuctionStart())
+	}
+	if legacy.BackupCell1() == nil || !bytes.Equal(legacy.BackupCell1().Hash(), backupCell.Hash()) {
+		t.Errorf("legacy BackupCell1 want %v, got %v", backupCell, legacy.BackupCell1())
+	}
+	if !legacy.IsLocked() || legacy.State() != UserState(1) {
+		t.Errorf("legacy user with state 1 should be locked")
+	}
+	if legacy.Principal(config.TON.ID()).Int64() != 1_000_000_000 {
+		t.Errorf("legacy Principal want %d, got %s", 1_000_000_000, legacy.Principal(config.TON.ID()))
+	}
+	if changed := legacy.ChangePrincipal(config.TON.ID(), big.NewInt(1)).(*UserSC); !changed.IsLegacy() || !changed.IsLocked() {
+		t.Errorf("ChangePrincipal should keep the user data")
+	}
+
+	rewards := NewUserSC(nil)
+	if _, err := rewards.SetAccData(header(4, 0).
+		MustStoreDict(nil).
+		MustStoreMaybeRef(nil).
+		MustStoreMaybeRef(backupCell).
+		EndCell()); err != nil {
+		t.Fatalf("rewards SetAccData err: %s", err)
+	}
+	if rewards.IsLegacy() || rewards.IsLocked() || rewards.BackupCell1() != nil || rewards.BackupCell2() == nil {
+		t.Errorf("old code version with the rewards layout should be decoded as rewards")
+	}
+
+	truncated := header(6, 0).MustStoreUInt(11, 64).EndCell()
+	if _, err := NewUserSC(nil).SetAccData(truncated); !errors.Is(err, ErrUnknownUserLayout) {
+		t.Errorf("SetAccData err want %s, got %v", ErrUnknownUserLayout, err)
+	}
+}
+
+func TestUserCS_SetData_layouts(t *testing.T) {
+	masterAddress := address.MustParseAddr("EQC8rUZqR_pWV1BylWUlPNBzyiTYVoBEmQkMIQDZXICfnuRr")
+	userAddress := address.MustParseAddr("UQBlB6eFlc-to_YqCabuBtSWFyY8uYm7Y6G39ADdiKvzi389")
+	backupCell := cell.BeginCell().MustStoreUInt(7, 8).EndCell()
+	stack := func(tail ...any) []any {
+		return append([]any{
+			big.NewInt(4),
+			cell.BeginCell().MustStoreAddr(masterAddress).EndCell().BeginParse(),
+			cell.BeginCell().MustStoreAddr(userAddress).EndCell().BeginParse(),
+			nil,
+			big.NewInt(0),
+		}, tail...)
+	}
+
+	legacy := NewUserSC(nil)
+	if _, err := legacy.SetData(ton.NewExecutionResult(stack(big.NewInt(11), big.NewInt(22), big.NewInt(1730106018), backupCell))); err != nil {
+		t.Fatalf("legacy SetData err: %s", err)
+	}
+	if !legacy.IsLegacy() || legacy.TrackingSupplyIndex().Int64() != 11 || legacy.TrackingBorrowIndex().Int64() != 22 || legacy.DutchAuctionStart() != 1730106018 {
+		t.Errorf("legacy fields want 11/22/1730106018, got %v/%s/%s/%d", legacy.IsLegacy(), legacy.TrackingSupplyIndex(), legacy.TrackingBorrowIndex(), legacy.DutchAuctionStart())
+	}
+	if legacy.BackupCell1() == nil || !bytes.Equal(legacy.BackupCell1().Hash(), backupCell.Hash()) {
+		t.Errorf("legacy BackupCell1 want %v, got %v", backupCell, legacy.BackupCell1())
+	}
+
+	rewards := NewUserSC(nil)
+	if _, err := rewards.SetData(ton.NewExecutionResult(stack(nil, nil, backupCell))); err != nil {
+		t.Fatalf("rewards SetData err: %s", err)
+	}
+	if rewards.IsLegacy() || rewards.BackupCell1() != nil || rewards.BackupCell2() == nil {
+		t.Errorf("rewards layout should be decoded as rewards")
+	}
+
+	for name, data := range map[string][]any{
+		"truncated":      stack(big.NewInt(11)),
+		"wrong type":     stack(big.NewInt(11), big.NewInt(22), backupCell, backupCell),
+		"no user state":  stack()[:4],
+		"no code":        {},
+		"invalid master": {big.NewInt(6), big.NewInt(1)},
+	} {
+		if _, err := NewUserSC(nil).SetData(ton.NewExecutionResult(data)); !errors.Is(err, ErrUnknownUserLayout) {
+			t.Errorf("%s: SetData err want %s, got %v", name, ErrUnknownUserLayout, err)
+		}
+	}
+}
+
+func TestUserCS_SetAccData_invalid(t *testing.T) {
+	for name, data := range map[string]*cell.Cell{
+		"empty":      cell.BeginCell().EndCell(),
+		"no address": cell.BeginCell().MustStoreCoins(6).EndCell(),
+	} {
+		if _, err := NewUserSC(nil).SetAccData(data); !errors.Is(err, ErrUnknownUserLayout) {
+			t.Errorf("%s: SetAccData err want %s, got %v", name, ErrUnknownUserLayout, err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+)
+
+// DefaultRepayAllMargin is the default RepayAllParameters.Margin, 0.1%.
+const DefaultRepayAllMargin = 10
+
+// RepayAllParameters describe a supply which closes a loan, the excess becomes a supply position.
+type RepayAllParameters struct {
+	SupplyParameters
+	// Debt is the loan balance expected when the message lands, e.g. principal.Repayment.Amount.
+	Debt *big.Int
+	// Margin in basis points is added to Debt for the interest accrued until the message actually lands,
+	// nil means DefaultRepayAllMargin.
+	Margin *uint64
+}
+
+// Supply returns the supply parameters with the amount sized to Debt plus Margin.
+func (p *RepayAllParameters) Supply() (*SupplyParameters, error) {
+	if p.Debt == nil || p.Debt.Sign() <= 0 {
+		return nil, fmt.Errorf("wrong debt value")
+	}
+	margin := uint64(DefaultRepayAllMargin)
+	if p.Margin != nil {
+		margin = *p.Margin
+	}
+	data := p.SupplyParameters
+	data.Amount = RepayAllAmount(p.Debt, margin)
+	return &data, nil
+}
+
+// RepayAllAmount returns debt increased by margin in basis points, rounded up.
+func RepayAllAmount(debt *big.Int, margin uint64) *big.Int {
+	extra := new(big.Int).Mul(debt, new(big.Int).SetUint64(margin))
+	extra.Add(extra, big.NewInt(9_999))
+	extra.Quo(extra, big.NewInt(10_000))
+	return extra.Add(extra, debt)
+}
+
+func (w *Wallet) SendRepayAll(ctx context.Context, data *RepayAllParameters, wait bool) error {
+	supply, err := data.Supply()
+	if err != nil {
+		return fmt.Errorf("failed to size repay amount, err: %w", err)
+	}
+	return w.SendSupply(ctx, supply, wait)
+}
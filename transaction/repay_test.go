@@ -0,0 +1,39 @@
+package transaction
+
+import (
+	"math/big"
+	"testing"
+)
+
+func margin(bps uint64) *uint64 {
+	return &bps
+}
+
+func TestRepayAllParameters_Supply(t *testing.T) {
+	for _, tt := range []struct {
+		debt   int64
+		margin *uint64
+		want   int64
+	}{
+		{1_000_000, nil, 1_001_000},
+		{1_000_000, new(uint64), 1_000_000},
+		{1_000_000, margin(50), 1_005_000},
+		{1_001, margin(10), 1_003},
+	} {
+		data := &RepayAllParameters{SupplyParameters: SupplyParameters{Asset: big.NewInt(1), QueryID: 7}, Debt: big.NewInt(tt.debt), Margin: tt.margin}
+		supply, err := data.Supply()
+		if err != nil {
+			t.Fatalf("failed to size supply, err: %s", err)
+		}
+		if supply.Amount.Cmp(big.NewInt(tt.want)) != 0 {
+			t.Errorf("Amount of %d with margin %v want %d, got %s", tt.debt, tt.margin, tt.want, supply.Amount)
+		}
+		if supply.QueryID != 7 || data.Amount != nil {
+			t.Errorf("supply parameters should be copied")
+		}
+	}
+
+	if _, err := (&RepayAllParameters{}).Supply(); err == nil {
+		t.Errorf("Supply without debt want error, got nil")
+	}
+}
@@ -0,0 +1,71 @@
+package units
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// Amount is an amount of the smallest units which is marshaled to JSON as an exact decimal string.
+type Amount struct {
+	Units    *big.Int
+	Decimals int
+}
+
+func NewAmount(units *big.Int, decimals int) *Amount {
+	return &Amount{Units: units, Decimals: decimals}
+}
+
+func (a *Amount) String() string {
+	if a.Units == nil {
+		return "0"
+	}
+	return Format(a.Units, a.Decimals)
+}
+
+func (a *Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON accepts a decimal string or number, Decimals has to be set beforehand.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n json.Number
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidAmount, data)
+		}
+		s = n.String()
+	}
+
+	units, err := Parse(s, a.Decimals)
+	if err != nil {
+		return err
+	}
+	a.Units = units
+	return nil
+}
+
+// USD is a USD value scaled by PriceDecimals which is marshaled to JSON as a decimal string with Precision decimals.
+type USD struct {
+	Value     *big.Int
+	Precision int
+}
+
+func NewUSD(value *big.Int, precision int) *USD {
+	return &USD{Value: value, Precision: precision}
+}
+
+func (u *USD) String() string {
+	if u.Value == nil {
+		return FormatUSD(new(big.Int), u.Precision)
+	}
+	return FormatUSD(u.Value, u.Precision)
+}
+
+func (u *USD) MarshalJSON() ([]byte, error) {
+	if u.Value == nil {
+		return json.Marshal(FormatFixed(new(big.Int), PriceDecimals, u.Precision))
+	}
+	return json.Marshal(FormatFixed(u.Value, PriceDecimals, u.Precision))
+}
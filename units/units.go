@@ -0,0 +1,110 @@
+// Package units converts token amounts and USD values between their on-chain integer
+// representation and human-readable decimal strings.
+package units
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+// PriceDecimals is the number of decimals of oracle prices and USD values, see config.AssetPriceScale.
+const PriceDecimals = 9
+
+var ErrInvalidAmount = errors.New("invalid amount")
+
+// ToRat returns the exact decimal value of an amount of the smallest units.
+func ToRat(amount *big.Int, decimals int) *big.Rat {
+	return new(big.Rat).SetFrac(amount, pow10(decimals))
+}
+
+// FromRat converts a decimal value to the smallest units rounding toward zero.
+func FromRat(value *big.Rat, decimals int) *big.Int {
+	units := new(big.Int).Mul(value.Num(), pow10(decimals))
+	return units.Quo(units, value.Denom())
+}
+
+// Format returns the exact decimal representation of an amount without trailing zeros, e.g. "12.5".
+func Format(amount *big.Int, decimals int) string {
+	s := ToRat(amount, decimals).FloatString(max(decimals, 0))
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// FormatFixed returns an amount with exactly precision decimals, rounded half away from zero.
+func FormatFixed(amount *big.Int, decimals, precision int) string {
+	return ToRat(amount, decimals).FloatString(max(precision, 0))
+}
+
+// Parse converts a decimal string like "1.5" into the smallest units.
+// Inputs with more fractional digits than decimals are rejected rather than rounded.
+func Parse(s string, decimals int) (*big.Int, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	unsigned := strings.TrimLeft(s, "+-")
+	if len(s)-len(unsigned) > 1 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	whole, fraction, _ := strings.Cut(unsigned, ".")
+	if whole == "" && fraction == "" || !isDigits(whole) || !isDigits(fraction) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if len(fraction) > decimals {
+		return nil, fmt.Errorf("%w: %q has more than %d decimals", ErrInvalidAmount, s, decimals)
+	}
+
+	units, _ := new(big.Int).SetString("0"+whole+fraction+strings.Repeat("0", decimals-len(fraction)), 10)
+	if negative {
+		units.Neg(units)
+	}
+	return units, nil
+}
+
+// FormatAsset returns an amount with the asset name, e.g. "12.5 tsTON".
+func FormatAsset(amount *big.Int, asset *config.AssetConfig) string {
+	return Format(amount, asset.Decimals) + " " + string(asset.Name)
+}
+
+// ParseAsset converts user input like "1.5" into the smallest units of the asset.
+func ParseAsset(s string, asset *config.AssetConfig) (*big.Int, error) {
+	return Parse(s, asset.Decimals)
+}
+
+// Value returns the USD value of an amount of the asset at an oracle price, both scaled by PriceDecimals.
+func Value(amount, price *big.Int, decimals int) *big.Int {
+	value := new(big.Int).Mul(amount, price)
+	return value.Quo(value, pow10(decimals))
+}
+
+// FormatUSD returns a USD value or price scaled by PriceDecimals with precision decimals, e.g. "$4.57".
+func FormatUSD(value *big.Int, precision int) string {
+	s := FormatFixed(value, PriceDecimals, precision)
+	if strings.HasPrefix(s, "-") {
+		return "-$" + s[1:]
+	}
+	return "$" + s
+}
+
+// FormatValue returns the USD value of an amount of the asset at an oracle price, e.g. "$57.19".
+func FormatValue(amount, price *big.Int, asset *config.AssetConfig, precision int) string {
+	return FormatUSD(Value(amount, price, asset.Decimals), precision)
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(max(n, 0))), nil)
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,138 @@
+package units
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/evaafi/evaa-go-sdk/config"
+)
+
+func TestPriceDecimals(t *testing.T) {
+	if pow10(PriceDecimals).Cmp(big.NewInt(config.AssetPriceScale)) != 0 {
+		t.Errorf("PriceDecimals does not match config.AssetPriceScale")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		amount   int64
+		decimals int
+		want     string
+	}{
+		{12_500_000_000, 9, "12.5"},
+		{1, 9, "0.000000001"},
+		{-1_500_000, 6, "-1.5"},
+		{42_000_000, 6, "42"},
+		{0, 6, "0"},
+		{7, 0, "7"},
+	}
+	for _, tt := range tests {
+		if got := Format(big.NewInt(tt.amount), tt.decimals); got != tt.want {
+			t.Errorf("Format(%d, %d) want %s, got %s", tt.amount, tt.decimals, tt.want, got)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		s        string
+		decimals int
+		want     int64
+		err      bool
+	}{
+		{"1.5", 9, 1_500_000_000, false},
+		{" 12.5 ", 6, 12_500_000, false},
+		{".5", 6, 500_000, false},
+		{"3.", 6, 3_000_000, false},
+		{"-0.000001", 6, -1, false},
+		{"+2", 0, 2, false},
+		{"0.0000001", 6, 0, true},
+		{"1.2.3", 6, 0, true},
+		{"1e5", 6, 0, true},
+		{"", 6, 0, true},
+		{".", 6, 0, true},
+		{"--1", 6, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.s, tt.decimals)
+		if tt.err {
+			if !errors.Is(err, ErrInvalidAmount) {
+				t.Errorf("Parse(%q) want %s, got %v", tt.s, ErrInvalidAmount, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q) err: %s", tt.s, err)
+			continue
+		}
+		if got.Cmp(big.NewInt(tt.want)) != 0 {
+			t.Errorf("Parse(%q) want %d, got %s", tt.s, tt.want, got)
+		}
+	}
+}
+
+func TestAsset(t *testing.T) {
+	tsTON := config.GetMainMainnetConfig().Assets[config.TSTON.ID()]
+	amount, err := ParseAsset("12.5", tsTON)
+	if err != nil {
+		t.Fatalf("failed to parse amount, err: %s", err)
+	}
+	if got := FormatAsset(amount, tsTON); got != "12.5 tsTON" {
+		t.Errorf("FormatAsset want %s, got %s", "12.5 tsTON", got)
+	}
+
+	price := big.NewInt(4_575_000_000)
+	if got := FormatUSD(price, 2); got != "$4.58" {
+		t.Errorf("FormatUSD want %s, got %s", "$4.58", got)
+	}
+	if got := FormatUSD(big.NewInt(-4_571_000_000), 2); got != "-$4.57" {
+		t.Errorf("FormatUSD want %s, got %s", "-$4.57", got)
+	}
+	if got := FormatValue(amount, price, tsTON, 2); got != "$57.19" {
+		t.Errorf("FormatValue want %s, got %s", "$57.19", got)
+	}
+	if got := Value(amount, price, tsTON.Decimals); got.Cmp(big.NewInt(57_187_500_000)) != 0 {
+		t.Errorf("Value want %d, got %s", 57_187_500_000, got)
+	}
+}
+
+func TestRat(t *testing.T) {
+	r := ToRat(big.NewInt(1_234_567), 6)
+	if r.Cmp(big.NewRat(1_234_567, 1_000_000)) != 0 {
+		t.Errorf("ToRat want %s, got %s", "1234567/1000000", r)
+	}
+	if got := FromRat(big.NewRat(1, 3), 6); got.Cmp(big.NewInt(333_333)) != 0 {
+		t.Errorf("FromRat want %d, got %s", 333_333, got)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	data, err := json.Marshal(struct {
+		Amount *Amount `json:"amount"`
+		Value  *USD    `json:"value"`
+	}{
+		Amount: NewAmount(big.NewInt(12_500_000), 6),
+		Value:  NewUSD(big.NewInt(57_187_500_000), 2),
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal, err: %s", err)
+	}
+	if string(data) != `{"amount":"12.5","value":"57.19"}` {
+		t.Errorf("json want %s, got %s", `{"amount":"12.5","value":"57.19"}`, data)
+	}
+
+	for _, input := range []string{`"1.5"`, `1.5`} {
+		amount := &Amount{Decimals: 9}
+		if err := json.Unmarshal([]byte(input), amount); err != nil {
+			t.Fatalf("failed to unmarshal %s, err: %s", input, err)
+		}
+		if amount.Units.Cmp(big.NewInt(1_500_000_000)) != 0 {
+			t.Errorf("Units of %s want %d, got %s", input, 1_500_000_000, amount.Units)
+		}
+	}
+	if err := json.Unmarshal([]byte(`true`), &Amount{Decimals: 9}); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("unmarshal of bool want %s, got %v", ErrInvalidAmount, err)
+	}
+}